@@ -0,0 +1,122 @@
+package expressgo
+
+import (
+	"strconv"
+	"strings"
+)
+
+// mediaRange is one media range of an Accept header, e.g. "text/*;q=0.5"
+type mediaRange struct {
+	typ     string
+	subtype string
+	params  map[string]string
+	q       float64
+}
+
+// parseAccept parses an Accept header into its media ranges. Malformed ranges are skipped.
+func parseAccept(header string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(header, ",") {
+		mr, ok := parseMediaRange(part)
+		if ok {
+			ranges = append(ranges, mr)
+		}
+	}
+	return ranges
+}
+
+// parseMediaRange parses a media range or media type with its parameters and quality
+func parseMediaRange(s string) (mediaRange, bool) {
+	parts := strings.Split(s, ";")
+	typ, subtype, ok := strings.Cut(strings.ToLower(strings.TrimSpace(parts[0])), "/")
+	if !ok || typ == "" || subtype == "" || (typ == "*" && subtype != "*") {
+		return mediaRange{}, false
+	}
+
+	mr := mediaRange{typ: typ, subtype: subtype, q: 1}
+	for _, param := range parts[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+		if key == "q" {
+			q, err := strconv.ParseFloat(value, 64)
+			if err != nil || q < 0 || q > 1 {
+				return mediaRange{}, false
+			}
+			mr.q = q
+			// parameters after the quality are accept extensions, not media type parameters
+			break
+		}
+		if key != "" {
+			if mr.params == nil {
+				mr.params = make(map[string]string)
+			}
+			mr.params[key] = value
+		}
+	}
+	return mr, true
+}
+
+// specificity ranks how closely the range matches the media type, or returns -1 if it does not.
+// An exact match outranks a structured syntax suffix match such as "application/json" for
+// "application/problem+json", which outranks "type/*" and "*/*".
+func (mr mediaRange) specificity(mt mediaRange) int {
+	var rank int
+	switch {
+	case mr.typ == "*":
+		rank = 0
+	case mr.typ != mt.typ:
+		return -1
+	case mr.subtype == "*":
+		rank = 1
+	case mr.subtype == mt.subtype:
+		rank = 3
+	case strings.HasSuffix(mt.subtype, "+"+mr.subtype):
+		rank = 2
+	default:
+		return -1
+	}
+
+	for key, value := range mr.params {
+		if !strings.EqualFold(mt.params[key], value) {
+			return -1
+		}
+	}
+	return rank*10 + len(mr.params)
+}
+
+// quality returns the quality the ranges give the media type: that of the most specific matching range
+func quality(ranges []mediaRange, mt mediaRange) float64 {
+	q, best := 0.0, -1
+	for _, mr := range ranges {
+		if s := mr.specificity(mt); s > best {
+			q, best = mr.q, s
+		}
+	}
+	return q
+}
+
+// negotiate returns the offered media type the Accept header prefers, or ""
+// if none is acceptable. Offers are tried in order, so the first of equally
+// acceptable offers wins; a missing Accept header accepts the first offer.
+func negotiate(header string, offers []string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	if strings.TrimSpace(header) == "" {
+		return offers[0]
+	}
+
+	ranges := parseAccept(header)
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		mt, ok := parseMediaRange(offer)
+		if !ok {
+			continue
+		}
+		if q := quality(ranges, mt); q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
@@ -0,0 +1,39 @@
+package expressgo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{"application/json", "application/xml", "text/html"}
+
+	tests := []struct {
+		accept   string
+		offers   []string
+		expected string
+	}{
+		{"", offers, "application/json"},
+		{"*/*", offers, "application/json"},
+		{"application/xml", offers, "application/xml"},
+		{"text/html, application/xml;q=0.9", offers, "text/html"},
+		{"application/json;q=0.5, application/xml", offers, "application/xml"},
+		{"text/*", offers, "text/html"},
+		{"*/*;q=0.1, application/xml;q=0.5", offers, "application/xml"},
+		{"application/*;q=0.2, application/xml;q=0, */*;q=0.1", offers, "application/json"},
+		{"image/png", offers, ""},
+		{"application/json;q=0", offers, ""},
+		{"application/json; charset=utf-8", []string{"application/json"}, ""},
+		{"application/json; charset=utf-8", []string{"application/json; charset=utf-8"}, "application/json; charset=utf-8"},
+		{"APPLICATION/JSON", offers, "application/json"},
+		{"application/json", []string{"application/problem+json"}, "application/problem+json"},
+		{"application/json, application/problem+xml", []string{"application/problem+json", "application/problem+xml"}, "application/problem+json"},
+		{"application/problem+json;q=0.5, application/json", []string{"application/problem+json"}, "application/problem+json"},
+		{"application/xml;q=abc, text/html", offers, "text/html"},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.expected, negotiate(tc.accept, tc.offers), "Unexpected offer for Accept %q", tc.accept)
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"net/http"
-	"strings"
+
+	"github.com/mikaeloduh/expressgo/e"
 )
 
 // JSONBodyEncoder is a middleware that configures the response writer to use JSON encoding.
-// It sets the Content-Type header to application/json if the client accepts JSON format.
+// It sets the Content-Type header to application/json if the client prefers JSON to the
+// formats of the encoders registered before, see ContentNegotiation.
 //
 // Parameters:
 //   - w: The Response to configure
@@ -18,12 +20,8 @@ import (
 // Returns:
 //   - error: Always returns nil as this middleware doesn't produce errors
 func JSONBodyEncoder(req *Request, res *Response, next func()) error {
-	res.UseEncoderDecorator(JSONEncoderDecorator)
-
-	accept := req.Header.Get("Accept")
-	if accept == "" || accept == "*/*" || strings.HasPrefix(accept, "application/json") {
-		res.Header().Set("Content-Type", "application/json")
-	}
+	res.UseEncoder("application/json", JSONEncoder)
+	res.negotiate(req)
 
 	next()
 
@@ -62,7 +60,8 @@ func JSONEncoder(w http.ResponseWriter, obj any) error {
 }
 
 // XMLBodyEncoder is a middleware that configures the response writer to use XML encoding.
-// It sets the Content-Type header to application/xml if the client accepts XML format.
+// It sets the Content-Type header to application/xml if the client prefers XML to the
+// formats of the encoders registered before, see ContentNegotiation.
 //
 // Parameters:
 //   - w: The Response to configure
@@ -72,11 +71,8 @@ func JSONEncoder(w http.ResponseWriter, obj any) error {
 // Returns:
 //   - error: Always returns nil as this middleware doesn't produce errors
 func XMLBodyEncoder(req *Request, res *Response, next func()) error {
-	res.UseEncoderDecorator(XMLEncoderDecorator)
-
-	if strings.HasPrefix(req.Header.Get("Accept"), "application/xml") {
-		res.Header().Set("Content-Type", "application/xml")
-	}
+	res.UseEncoder("application/xml", XMLEncoder)
+	res.negotiate(req)
 
 	next()
 
@@ -113,3 +109,22 @@ func XMLEncoderDecorator(next Encoder) Encoder {
 func XMLEncoder(w http.ResponseWriter, obj any) error {
 	return xml.NewEncoder(w).Encode(obj)
 }
+
+// ContentNegotiation is a middleware that chooses the response format among the
+// encoders registered on the response, such as by JSONBodyEncoder and
+// XMLBodyEncoder, so it must run after them. It picks the format the Accept
+// header of the request prefers, honouring quality values, wildcards and media
+// type parameters, and the format registered first when several are equally
+// acceptable. It sets the Content-Type accordingly and adds Accept to the Vary
+// header. If no format is acceptable, it returns e.ErrorTypeNotAcceptable.
+//
+//	router.Use(expressgo.JSONBodyEncoder, expressgo.XMLBodyEncoder, expressgo.ContentNegotiation)
+func ContentNegotiation(req *Request, res *Response, next func()) error {
+	if len(res.mediaTypes) > 0 && !res.negotiate(req) {
+		return e.ErrorTypeNotAcceptable
+	}
+
+	next()
+
+	return nil
+}
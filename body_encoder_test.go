@@ -49,3 +49,47 @@ func TestWriteObjectAsXML(t *testing.T) {
 	assert.Equal(t, "application/xml", wr.Header().Get("Content-Type"))
 	assert.Equal(t, string(expected), wr.Body.String())
 }
+
+func TestContentNegotiation(t *testing.T) {
+	testObject := TestObject{Username: "John Doe", Email: "jd@example.com", Id: 1}
+	handler := func(_ *Request, res *Response) error {
+		return res.Encode(testObject)
+	}
+
+	jsonFirst := NewRouter()
+	jsonFirst.Use(JSONBodyEncoder, XMLBodyEncoder, ContentNegotiation)
+	jsonFirst.Get("/user", handler)
+
+	xmlFirst := NewRouter()
+	xmlFirst.Use(XMLBodyEncoder, JSONBodyEncoder, ContentNegotiation)
+	xmlFirst.Get("/user", handler)
+
+	tests := []struct {
+		name                string
+		router              *Router
+		accept              string
+		expectedCode        int
+		expectedContentType string
+	}{
+		{"no accept", jsonFirst, "", http.StatusOK, "application/json"},
+		{"no accept, xml first", xmlFirst, "", http.StatusOK, "application/xml"},
+		{"quality values", jsonFirst, "text/html, application/xml;q=0.9, application/json;q=0.8", http.StatusOK, "application/xml"},
+		{"quality values, xml first", xmlFirst, "application/json, application/xml;q=0.5", http.StatusOK, "application/json"},
+		{"wildcard", xmlFirst, "application/*", http.StatusOK, "application/xml"},
+		{"excluded", jsonFirst, "*/*, application/json;q=0", http.StatusOK, "application/xml"},
+		{"parameters", jsonFirst, "application/json;version=2, application/xml;q=0.1", http.StatusOK, "application/xml"},
+		{"not acceptable", jsonFirst, "text/html", http.StatusNotAcceptable, "text/html; charset=utf-8"},
+	}
+
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/user", nil)
+		req.Header.Set("Accept", tc.accept)
+		rr := httptest.NewRecorder()
+
+		tc.router.ServeHTTP(rr, req)
+
+		assert.Equal(t, tc.expectedCode, rr.Code, "Unexpected status for %s", tc.name)
+		assert.Equal(t, tc.expectedContentType, rr.Header().Get("Content-Type"), "Unexpected Content-Type for %s", tc.name)
+		assert.Equal(t, []string{"Accept"}, rr.Header().Values("Vary"), "Unexpected Vary for %s", tc.name)
+	}
+}
@@ -0,0 +1,96 @@
+package expressgo
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Codec encodes responses and decodes request bodies of one media type. Either
+// function may be nil for formats that are only read or only written.
+type Codec struct {
+	Encoder Encoder
+	Decoder Decoder
+}
+
+// CodecRegistry holds codecs by media type. Its Middleware parses request bodies
+// and encodes responses with them, so that adding a format takes one
+// registration. Codecs must be registered before the router serves requests.
+type CodecRegistry struct {
+	mediaTypes []string
+	codecs     map[string]Codec
+}
+
+// DefaultCodecs is the registry of the JSON and XML codecs
+var DefaultCodecs = NewCodecRegistry()
+
+func init() {
+	DefaultCodecs.Register("application/json", Codec{Encoder: JSONEncoder, Decoder: JSONDecoder})
+	DefaultCodecs.Register("application/xml", Codec{Encoder: XMLEncoder, Decoder: XMLDecoder})
+}
+
+// NewCodecRegistry creates an empty codec registry
+func NewCodecRegistry() *CodecRegistry {
+	return &CodecRegistry{codecs: make(map[string]Codec)}
+}
+
+// Register registers the codec for the media type, replacing any codec registered for it before
+func (r *CodecRegistry) Register(mediaType string, codec Codec) {
+	mediaType = strings.ToLower(mediaType)
+	if _, ok := r.codecs[mediaType]; !ok {
+		r.mediaTypes = append(r.mediaTypes, mediaType)
+	}
+	r.codecs[mediaType] = codec
+}
+
+// Lookup returns the codec for the media type of a Content-Type header value.
+// A media type with a structured syntax suffix falls back to the codec of the
+// suffix, so "application/problem+json" is handled by the "application/json"
+// codec unless it has a codec of its own.
+func (r *CodecRegistry) Lookup(contentType string) (Codec, bool) {
+	mt, ok := parseMediaRange(contentType)
+	if !ok {
+		return Codec{}, false
+	}
+	if codec, ok := r.codecs[mt.typ+"/"+mt.subtype]; ok {
+		return codec, true
+	}
+	if i := strings.LastIndexByte(mt.subtype, '+'); i >= 0 {
+		codec, ok := r.codecs[mt.typ+"/"+mt.subtype[i+1:]]
+		return codec, ok
+	}
+	return Codec{}, false
+}
+
+// Middleware sets the decoder of the request to the codec of its Content-Type,
+// and lets the response encode every registered format, choosing the one the
+// Accept header prefers like JSONBodyEncoder does. Add ContentNegotiation
+// after it to reject requests accepting none of them.
+//
+//	router.Use(expressgo.DefaultCodecs.Middleware)
+func (r *CodecRegistry) Middleware(req *Request, res *Response, next func()) error {
+	if codec, ok := r.Lookup(req.Header.Get("Content-Type")); ok && codec.Decoder != nil {
+		req.SetDecoder(codec.Decoder)
+	}
+
+	res.UseEncoderDecorator(r.encoderDecorator)
+	for _, mediaType := range r.mediaTypes {
+		if r.codecs[mediaType].Encoder != nil {
+			res.mediaTypes = append(res.mediaTypes, mediaType)
+		}
+	}
+	res.negotiate(req)
+
+	next()
+
+	return nil
+}
+
+// encoderDecorator encodes with the codec of the Content-Type of the response, if any
+func (r *CodecRegistry) encoderDecorator(next Encoder) Encoder {
+	return func(w http.ResponseWriter, obj any) error {
+		if codec, ok := r.Lookup(w.Header().Get("Content-Type")); ok && codec.Encoder != nil {
+			return codec.Encoder(w, obj)
+		}
+		return next(w, obj)
+	}
+}
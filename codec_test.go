@@ -0,0 +1,104 @@
+package expressgo
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodecRegistryLookup(t *testing.T) {
+	problem := Codec{Encoder: JSONEncoder}
+	registry := NewCodecRegistry()
+	registry.Register("application/json", Codec{Encoder: JSONEncoder, Decoder: JSONDecoder})
+	registry.Register("Application/Problem+JSON", problem)
+
+	tests := []struct {
+		contentType string
+		found       bool
+		decodes     bool
+	}{
+		{"application/json", true, true},
+		{"application/json; charset=utf-8", true, true},
+		{"APPLICATION/JSON", true, true},
+		{"application/vnd.api+json", true, true},
+		{"application/problem+json", true, false},
+		{"application/xml", false, false},
+		{"application/jsonx", false, false},
+		{"", false, false},
+	}
+
+	for _, tc := range tests {
+		codec, ok := registry.Lookup(tc.contentType)
+		assert.Equal(t, tc.found, ok, "Unexpected lookup result for %q", tc.contentType)
+		assert.Equal(t, tc.decodes, codec.Decoder != nil, "Unexpected decoder for %q", tc.contentType)
+	}
+}
+
+func TestCodecRegistryMiddleware(t *testing.T) {
+	registry := NewCodecRegistry()
+	registry.Register("application/json", Codec{Encoder: JSONEncoder, Decoder: JSONDecoder})
+	registry.Register("application/xml", Codec{Encoder: XMLEncoder, Decoder: XMLDecoder})
+	registry.Register("text/plain", Codec{
+		Encoder: func(w http.ResponseWriter, v any) error {
+			_, err := fmt.Fprintf(w, "%s <%s>", v.(TestObject).Username, v.(TestObject).Email)
+			return err
+		},
+		Decoder: func(r io.Reader, v any) error {
+			b, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			name, email, _ := strings.Cut(string(b), " ")
+			*v.(*TestObject) = TestObject{Username: name, Email: strings.Trim(email, "<>")}
+			return nil
+		},
+	})
+
+	route := NewRouter()
+	route.Use(registry.Middleware, ContentNegotiation)
+	route.Post("/users", func(req *Request, res *Response) error {
+		var user TestObject
+		if err := req.ParseBodyInto(&user); err != nil {
+			return err
+		}
+		user.Id = 7
+		return res.Status(http.StatusCreated).Send(user)
+	})
+
+	tests := []struct {
+		name                string
+		contentType         string
+		body                string
+		accept              string
+		expectedCode        int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{"json", "application/json", `{"username":"ann","email":"ann@example.com"}`, "",
+			http.StatusCreated, "application/json", `{"username":"ann","email":"ann@example.com","id":7}` + "\n"},
+		{"json suffix to xml", "application/vnd.user+json", `{"username":"ann","email":"ann@example.com"}`, "application/xml",
+			http.StatusCreated, "application/xml", `<TestObject><username>ann</username><email>ann@example.com</email><id>7</id></TestObject>`},
+		{"text", "text/plain", "ann <ann@example.com>", "text/plain, application/json;q=0.5",
+			http.StatusCreated, "text/plain", "ann <ann@example.com>"},
+		{"not acceptable", "application/json", `{}`, "text/csv",
+			http.StatusNotAcceptable, "text/plain; charset=utf-8", "Not Acceptable"},
+	}
+
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", tc.contentType)
+		req.Header.Set("Accept", tc.accept)
+		rr := httptest.NewRecorder()
+
+		route.ServeHTTP(rr, req)
+
+		assert.Equal(t, tc.expectedCode, rr.Code, "Unexpected status for %s", tc.name)
+		assert.Equal(t, tc.expectedContentType, rr.Header().Get("Content-Type"), "Unexpected Content-Type for %s", tc.name)
+		assert.Equal(t, tc.expectedBody, rr.Body.String(), "Unexpected body for %s", tc.name)
+	}
+}
@@ -0,0 +1,76 @@
+package expressgo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestConcurrentRegistration serves requests while routes are added and removed, run it with -race
+func TestConcurrentRegistration(t *testing.T) {
+	route := NewRouter()
+	route.Get("/static", homeHandler)
+	route.Get("/users/:id", homeHandler)
+	api := route.Group("/api")
+	admin := NewRouter()
+	admin.Get("/stats", homeHandler)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				path := fmt.Sprintf("/plugins/p%d/r%d/:id", i, j)
+				api.Get(path, homeHandler).Name(fmt.Sprintf("p%d-r%d", i, j))
+				if j%2 == 0 {
+					api.Remove(http.MethodGet, path)
+				}
+			}
+		}(i)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		route.Mount("/admin", admin)
+		route.Host("{tenant}.example.com").Get("/", homeHandler)
+	}()
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				for _, path := range []string{"/static", "/users/42", "/api/plugins/p0/r1/7"} {
+					rr := httptest.NewRecorder()
+					route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+					if path != "/api/plugins/p0/r1/7" {
+						assert.Equal(t, http.StatusOK, rr.Code, "Unexpected status for %s", path)
+					}
+				}
+				route.Routes()
+				_, _ = route.URL("p1-r1", "id", "7")
+			}
+		}()
+	}
+	wg.Wait()
+
+	routes := route.Routes()
+	assert.Len(t, routes, 2+4*100+2)
+
+	rr := httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/plugins/p3/r199/7", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/plugins/p3/r198/7", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	_, err := route.URL("p3-r198", "id", "7")
+	assert.Error(t, err)
+}
@@ -0,0 +1,85 @@
+package expressgo
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+)
+
+// paramTypes maps the built-in parameter types to the expressions they stand for
+var paramTypes = map[string]string{
+	"int":   `[0-9]+`,
+	"uuid":  `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+	"alpha": `[a-zA-Z]+`,
+	"slug":  `[a-z0-9]+(?:-[a-z0-9]+)*`,
+}
+
+// constraint restricts the values a path parameter matches
+type constraint struct {
+	expr string
+	re   *regexp.Regexp
+
+	// canonical is the simplified expression, the same for expressions only written
+	// differently, such as `[0-9]+` and `\d+`
+	canonical string
+}
+
+// match reports whether the parameter value satisfies the constraint; a nil constraint matches any value
+func (c *constraint) match(value string) bool {
+	return c == nil || c.re.MatchString(value)
+}
+
+// String returns the expression of the constraint, or "" for a nil constraint
+func (c *constraint) String() string {
+	if c == nil {
+		return ""
+	}
+	return c.expr
+}
+
+// equal reports whether the constraints are the same once simplified. Constraints
+// that differ can still overlap, in which case the first registered is tried first.
+func (c *constraint) equal(other *constraint) bool {
+	if c == nil || other == nil {
+		return c == other
+	}
+	return c.canonical == other.canonical
+}
+
+// parseParam parses a parameter token, either ":name", "{name}" or "{name:constraint}",
+// where constraint is a built-in type name or a regular expression matching the whole value
+func parseParam(token string) (string, *constraint, error) {
+	var name, expr string
+	switch {
+	case token[0] == ':':
+		name = token[1:]
+	case token[0] == '{' && token[len(token)-1] == '}':
+		name, expr, _ = strings.Cut(token[1:len(token)-1], ":")
+	default:
+		return "", nil, fmt.Errorf("must be a whole path segment")
+	}
+
+	if name == "" {
+		return "", nil, fmt.Errorf("missing name")
+	}
+	if expr == "" {
+		return name, nil, nil
+	}
+
+	if t, ok := paramTypes[expr]; ok {
+		expr = t
+	}
+	if strings.Contains(expr, "/") {
+		return "", nil, fmt.Errorf("constraint cannot match \"/\"")
+	}
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return "", nil, err
+	}
+	parsed, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return "", nil, err
+	}
+	return name, &constraint{expr: expr, re: re, canonical: parsed.Simplify().String()}, nil
+}
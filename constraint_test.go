@@ -0,0 +1,114 @@
+package expressgo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParamConstraints(t *testing.T) {
+	route := NewRouter()
+
+	respond := func(kind string, param string) func(*Request, *Response) error {
+		return func(req *Request, res *Response) error {
+			fmt.Fprintf(res, "%s %s", kind, req.Param(param))
+			return nil
+		}
+	}
+
+	route.Get("/users/{id:int}", respond("id", "id"))
+	route.Get("/users/{name:alpha}", respond("name", "name"))
+	route.Get("/users/{handle}", respond("handle", "handle"))
+	route.Get("/files/{file:uuid}", respond("file", "file"))
+	route.Get("/posts/{slug:slug}", respond("slug", "slug"))
+	route.Get("/codes/{code:[A-Z]{3}}", respond("code", "code"))
+
+	tests := []struct {
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{"/users/42", http.StatusOK, "id 42"},
+		{"/users/alice", http.StatusOK, "name alice"},
+		{"/users/alice_42", http.StatusOK, "handle alice_42"},
+		{"/files/123e4567-e89b-12d3-a456-426614174000", http.StatusOK, "file 123e4567-e89b-12d3-a456-426614174000"},
+		{"/files/not-a-uuid", http.StatusNotFound, "Cannot find the path \"/files/not-a-uuid\""},
+		{"/posts/hello-world-2", http.StatusOK, "slug hello-world-2"},
+		{"/posts/Hello_World", http.StatusNotFound, "Cannot find the path \"/posts/Hello_World\""},
+		{"/codes/ABC", http.StatusOK, "code ABC"},
+		{"/codes/ABCD", http.StatusNotFound, "Cannot find the path \"/codes/ABCD\""},
+	}
+
+	for _, tc := range tests {
+		rr := httptest.NewRecorder()
+		route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		assert.Equal(t, tc.expectedCode, rr.Code, "Unexpected status code for path %s", tc.path)
+		assert.Equal(t, tc.expectedBody, rr.Body.String(), "Unexpected response body for path %s", tc.path)
+	}
+}
+
+func TestParamConstraintFallThrough(t *testing.T) {
+	route := NewRouter()
+	route.Get("/items/{id:int}/detail", func(req *Request, res *Response) error {
+		fmt.Fprintf(res, "detail %s", req.Param("id"))
+		return nil
+	})
+	route.Get("/items/*rest", func(req *Request, res *Response) error {
+		fmt.Fprintf(res, "rest %s", req.Param("rest"))
+		return nil
+	})
+
+	rr := httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/items/7/detail", nil))
+	assert.Equal(t, "detail 7", rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/items/x/detail", nil))
+	assert.Equal(t, "rest x/detail", rr.Body.String())
+}
+
+func TestParamConstraintConflicts(t *testing.T) {
+	tests := []struct {
+		existing string
+		pattern  string
+	}{
+		{"/users/{id:int}", "/users/{uid:int}"},
+		{"/users/{id:int}", "/users/{uid:[0-9]+}"},
+		{"/users/{id:[0-9]+}", "/users/{uid:\\d+}"},
+		{"/users/{id:[a-c]+}", "/users/{uid:(?:a|b|c)+}"},
+		{"/users/{id}", "/users/:uid"},
+		{"/", "/users/{id:[0-9}"},
+		{"/", "/users/{id:.+/.+}"},
+		{"/", "/users/{id}x"},
+		{"/", "/users/{:int}"},
+	}
+
+	for _, tc := range tests {
+		route := NewRouter()
+		route.Get(tc.existing, homeHandler)
+		assert.Panics(t, func() { route.Get(tc.pattern, homeHandler) }, "Expected %q to conflict with %q", tc.pattern, tc.existing)
+	}
+
+	route := NewRouter()
+	route.Get("/users/{id:int}", homeHandler)
+	assert.NotPanics(t, func() { route.Post("/users/{id:[0-9]+}", homeHandler) })
+	assert.NotPanics(t, func() { route.Get("/users/{name:alpha}", homeHandler) })
+	assert.NotPanics(t, func() { route.Get("/users/:handle", homeHandler) })
+	assert.NotPanics(t, func() { route.Get("/users/{hex:[0-9a-f]+}", homeHandler) })
+}
+
+func TestConstrainedRouteURL(t *testing.T) {
+	route := NewRouter()
+	route.Get("/users/{id:int}", homeHandler).Name("user")
+
+	u, err := route.URL("user", "id", "42")
+	assert.NoError(t, err)
+	assert.Equal(t, "/users/42", u)
+
+	_, err = route.URL("user", "id", "abc")
+	assert.EqualError(t, err, "expressgo: route \"user\": parameter \"id\" does not match \"[0-9]+\"")
+}
@@ -1,8 +1,22 @@
 package expressgo
 
-import "io"
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+)
 
 // Decoder is a function type that defines an interface for decoding data.
 // It represents a function that takes an io.Reader and a destination object,
 // then decodes the data from the reader into the provided object.
 type Decoder func(io.Reader, any) error
+
+// JSONDecoder decodes JSON data from an io.Reader into the provided value.
+func JSONDecoder(r io.Reader, v any) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+// XMLDecoder decodes XML data from an io.Reader into the provided value.
+func XMLDecoder(r io.Reader, v any) error {
+	return xml.NewDecoder(r).Decode(v)
+}
@@ -1,6 +1,9 @@
 package e
 
-import "net/http"
+import (
+	"maps"
+	"net/http"
+)
 
 var (
 	ErrorTypeBadRequest       = NewError(http.StatusBadRequest, nil)       // 400
@@ -8,6 +11,7 @@ var (
 	ErrorTypeForbidden        = NewError(http.StatusForbidden, nil)        // 403
 	ErrorTypeNotFound         = NewError(http.StatusNotFound, nil)         // 404
 	ErrorTypeMethodNotAllowed = NewError(http.StatusMethodNotAllowed, nil) // 405
+	ErrorTypeNotAcceptable    = NewError(http.StatusNotAcceptable, nil)    // 406
 
 	ErrorTypeInternalServerError = NewError(http.StatusInternalServerError, nil) // 500
 )
@@ -16,6 +20,18 @@ var (
 type Error struct {
 	Code int
 	Err  error
+
+	// Problem details of the error, see RFC 9457. Type and Instance are URI
+	// references, Title defaults to the status text of Code, and Extensions are
+	// additional members of the problem object.
+	Type       string
+	Title      string
+	Detail     string
+	Instance   string
+	Extensions map[string]any
+
+	// origin is the error this one was derived from by a With method
+	origin *Error
 }
 
 func (e *Error) Error() string {
@@ -25,6 +41,68 @@ func (e *Error) Error() string {
 	return http.StatusText(e.Code)
 }
 
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error was derived from target by the With methods,
+// so that errors.Is(ErrorTypeNotFound.WithDetail("..."), ErrorTypeNotFound) holds
+func (e *Error) Is(target error) bool {
+	for o := e.origin; o != nil; o = o.origin {
+		if o == target {
+			return true
+		}
+	}
+	return false
+}
+
+// WithType returns a copy of the error with the problem type set
+func (e *Error) WithType(uri string) *Error {
+	c := e.derive()
+	c.Type = uri
+	return c
+}
+
+// WithTitle returns a copy of the error with the problem title set
+func (e *Error) WithTitle(title string) *Error {
+	c := e.derive()
+	c.Title = title
+	return c
+}
+
+// WithDetail returns a copy of the error with the problem detail set
+func (e *Error) WithDetail(detail string) *Error {
+	c := e.derive()
+	c.Detail = detail
+	return c
+}
+
+// WithInstance returns a copy of the error with the problem instance set
+func (e *Error) WithInstance(uri string) *Error {
+	c := e.derive()
+	c.Instance = uri
+	return c
+}
+
+// WithExtension returns a copy of the error with the extension member key set to value
+func (e *Error) WithExtension(key string, value any) *Error {
+	c := e.derive()
+	c.Extensions = maps.Clone(e.Extensions)
+	if c.Extensions == nil {
+		c.Extensions = make(map[string]any)
+	}
+	c.Extensions[key] = value
+	return c
+}
+
+// derive returns a copy of the error that is still identified with it by errors.Is
+func (e *Error) derive() *Error {
+	c := *e
+	c.origin = e
+	return &c
+}
+
 // NewError
 func NewError(code int, err error) *Error {
 	return &Error{
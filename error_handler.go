@@ -1,8 +1,12 @@
 package expressgo
 
 import (
+	"bytes"
+	"encoding/xml"
 	"errors"
 	"fmt"
+	"html/template"
+	"io"
 	"net/http"
 	"strings"
 
@@ -12,14 +16,213 @@ import (
 // ErrorHandlerFunc is an interface of error handler
 type ErrorHandlerFunc func(err error, req *Request, res *Response, next func(error))
 
+// ErrorData is the data rendered by the default error handlers
+type ErrorData struct {
+	XMLName xml.Name `json:"-" xml:"error"`
+	Status  int      `json:"status" xml:"status"`
+	Title   string   `json:"title" xml:"title"`
+	Message string   `json:"message" xml:"message"`
+	Path    string   `json:"path" xml:"path"`
+}
+
+// ErrorTemplate renders the body of an error response
+type ErrorTemplate func(w io.Writer, data ErrorData) error
+
+// NewErrorTemplate returns an ErrorTemplate executing a text/template or html/template template
+func NewErrorTemplate(t interface {
+	Execute(w io.Writer, data any) error
+}) ErrorTemplate {
+	return func(w io.Writer, data ErrorData) error {
+		return t.Execute(w, data)
+	}
+}
+
+// errorContentTypes are the formats the default error handlers render, by media type.
+// Plain text comes first so that clients without preference get the plain text message.
+var errorContentTypes = []struct {
+	mediaType   string
+	contentType string
+}{
+	{"text/plain", "text/plain; charset=utf-8"},
+	{"application/json", "application/json"},
+	{"application/xml", "application/xml"},
+	{"text/html", "text/html; charset=utf-8"},
+}
+
+var defaultHTMLErrorTemplate = template.Must(template.New("error").Parse(
+	`<!DOCTYPE html>
+<html>
+<head><title>{{.Status}} {{.Title}}</title></head>
+<body>
+<h1>{{.Status}} {{.Title}}</h1>
+<p>{{.Message}}</p>
+</body>
+</html>
+`))
+
+// SetErrorTemplate sets the template the default error handlers render responses
+// of the media type with, for the routes of the router and its groups. The media
+// type is one of "text/plain", "application/json", "application/xml" and
+// "text/html", whose default rendering it replaces, or another media type the
+// error responses can then be negotiated to.
+func (rt *Router) SetErrorTemplate(mediaType string, tmpl ErrorTemplate) {
+	if rt.errorTemplates == nil {
+		rt.errorTemplates = make(map[string]ErrorTemplate)
+	}
+	rt.errorTemplates[strings.ToLower(mediaType)] = tmpl
+}
+
+// errorTemplate returns the error template of the router or its nearest parent for the media type
+func (rt *Router) errorTemplate(mediaType string) ErrorTemplate {
+	for r := rt; r != nil; r = r.parent {
+		if tmpl, ok := r.errorTemplates[mediaType]; ok {
+			return tmpl
+		}
+	}
+	return nil
+}
+
+// errorMediaTypes returns the media types error responses can be rendered in, built-in formats first
+func (rt *Router) errorMediaTypes() []string {
+	mediaTypes := make([]string, 0, len(errorContentTypes))
+	for _, ct := range errorContentTypes {
+		mediaTypes = append(mediaTypes, ct.mediaType)
+	}
+	for r := rt; r != nil; r = r.parent {
+		for mediaType := range r.errorTemplates {
+			if !contains(mediaTypes, mediaType) {
+				mediaTypes = append(mediaTypes, mediaType)
+			}
+		}
+	}
+	return mediaTypes
+}
+
+// writeError writes an error response in the format the request accepts best,
+// using the error templates of the router handling the request, unless the
+// response is already committed. JSON and XML
+// bodies are encoded by the encoder chain of the response when it supports them.
+func writeError(req *Request, res *Response, status int, message string) {
+	if res.Committed() {
+		return
+	}
+
+	data := ErrorData{
+		Status:  status,
+		Title:   http.StatusText(status),
+		Message: message,
+		Path:    req.URL.Path,
+	}
+
+	addVary(res.Header(), "Accept")
+	mediaType := negotiate(req.Header.Get("Accept"), preferResponseType(res, req.router.errorMediaTypes()))
+	contentType := mediaType
+	for _, ct := range errorContentTypes {
+		if ct.mediaType == mediaType {
+			contentType = ct.contentType
+		}
+	}
+
+	body, err := renderError(req.router.errorTemplate(mediaType), res, mediaType, contentType, data)
+	if err != nil || mediaType == "" {
+		contentType = "text/plain; charset=utf-8"
+		body = []byte(message)
+	}
+
+	res.Header().Set("Content-Type", contentType)
+	res.WriteHeader(status)
+	_, _ = res.Write(body)
+}
+
+// preferResponseType moves the media type of the Content-Type already set on the
+// response, such as by JSONBodyEncoder, to the front of mediaTypes, so that errors
+// are rendered like successful responses when the request accepts several formats
+func preferResponseType(res *Response, mediaTypes []string) []string {
+	mt, ok := parseMediaRange(res.Header().Get("Content-Type"))
+	if !ok {
+		return mediaTypes
+	}
+	current := mt.typ + "/" + mt.subtype
+	for i, mediaType := range mediaTypes {
+		if mediaType == current {
+			return append(append([]string{current}, mediaTypes[:i]...), mediaTypes[i+1:]...)
+		}
+	}
+	return mediaTypes
+}
+
+// renderError renders the error data with the template, or else in the built-in format of the media type
+func renderError(tmpl ErrorTemplate, res *Response, mediaType string, contentType string, data ErrorData) ([]byte, error) {
+	var body bytes.Buffer
+	if tmpl != nil {
+		err := tmpl(&body, data)
+		return body.Bytes(), err
+	}
+
+	switch mediaType {
+	case "application/json", "application/xml":
+		// encode through the encoder chain, which selects the encoder by Content-Type
+		w := &bufferResponseWriter{header: http.Header{"Content-Type": {contentType}}}
+		if err := res.encoder(w, data); err == nil {
+			return w.body.Bytes(), nil
+		}
+		w.body.Reset()
+		encode := JSONEncoder
+		if mediaType == "application/xml" {
+			encode = XMLEncoder
+		}
+		err := encode(w, data)
+		return w.body.Bytes(), err
+	case "text/html":
+		err := defaultHTMLErrorTemplate.Execute(&body, data)
+		return body.Bytes(), err
+	}
+	return []byte(data.Message), nil
+}
+
+// bufferResponseWriter collects a response body in memory
+type bufferResponseWriter struct {
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (w *bufferResponseWriter) Header() http.Header {
+	if w.header == nil {
+		w.header = make(http.Header)
+	}
+	return w.header
+}
+
+func (w *bufferResponseWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *bufferResponseWriter) WriteHeader(int) {}
+
+// contains reports whether s is one of values
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// isErrorType reports whether er is the error type or was derived from it with
+// the With methods. Unlike errors.Is, it does not look into the error er wraps,
+// so that e.NewError(500, e.ErrorTypeNotFound) is not answered as a 404; the
+// default handlers matched only the outer *e.Error before e.Error had Unwrap.
+func isErrorType(er *e.Error, target *e.Error) bool {
+	return er == target || er.Is(target)
+}
+
 // DefaultNotFoundErrorHandler return 404 page not found with detail message
 func DefaultNotFoundErrorHandler(err error, req *Request, res *Response, next func(error)) {
 	var er *e.Error
 	if errors.As(err, &er) {
-		if errors.Is(er, e.ErrorTypeNotFound) {
-			res.WriteHeader(er.Code)
-			res.Header().Set("Content-Type", "text/plain; charset=utf-8")
-			_, _ = res.Write([]byte(fmt.Sprintf("Cannot find the path \"%v\"", req.URL.Path)))
+		if isErrorType(er, e.ErrorTypeNotFound) {
+			writeError(req, res, er.Code, fmt.Sprintf("Cannot find the path \"%v\"", req.URL.Path))
 			return
 		}
 	}
@@ -31,14 +234,12 @@ func DefaultNotFoundErrorHandler(err error, req *Request, res *Response, next fu
 func DefaultMethodNotAllowedErrorHandler(err error, req *Request, res *Response, next func(error)) {
 	var er *e.Error
 	if errors.As(err, &er) {
-		if errors.Is(er, e.ErrorTypeMethodNotAllowed) {
-			res.WriteHeader(er.Code)
+		if isErrorType(er, e.ErrorTypeMethodNotAllowed) {
 			path := strings.Trim(req.URL.Path, "/")
 			if path == "" {
 				path = "/"
 			}
-			res.Header().Set("Content-Type", "text/plain; charset=utf-8")
-			_, _ = res.Write([]byte(fmt.Sprintf("Method \"%v\" is not allowed on path \"%v\"", req.Method, path)))
+			writeError(req, res, er.Code, fmt.Sprintf("Method \"%v\" is not allowed on path \"%v\"", req.Method, path))
 			return
 		}
 	}
@@ -46,13 +247,11 @@ func DefaultMethodNotAllowedErrorHandler(err error, req *Request, res *Response,
 	next(err)
 }
 
-func DefaultUnauthorizedErrorHandler(err error, _ *Request, res *Response, next func(error)) {
+func DefaultUnauthorizedErrorHandler(err error, req *Request, res *Response, next func(error)) {
 	var er *e.Error
 	if errors.As(err, &er) {
-		if errors.Is(er, e.ErrorTypeUnauthorized) {
-			res.WriteHeader(er.Code)
-			res.Header().Set("Content-Type", "text/plain; charset=utf-8")
-			_, _ = res.Write([]byte("401 unauthorized"))
+		if isErrorType(er, e.ErrorTypeUnauthorized) {
+			writeError(req, res, er.Code, "401 unauthorized")
 			return
 		}
 	}
@@ -61,16 +260,12 @@ func DefaultUnauthorizedErrorHandler(err error, _ *Request, res *Response, next
 }
 
 // DefaultFallbackErrorHandler catch all remaining errors
-func DefaultFallbackErrorHandler(err error, _ *Request, res *Response, _ func(error)) {
+func DefaultFallbackErrorHandler(err error, req *Request, res *Response, _ func(error)) {
 	var er *e.Error
 	if errors.As(err, &er) {
-		res.WriteHeader(er.Code)
-		res.Header().Set("Content-Type", "text/plain; charset=utf-8")
-		_, _ = res.Write([]byte(er.Error()))
+		writeError(req, res, er.Code, er.Error())
 		return
 	}
 
-	res.WriteHeader(http.StatusInternalServerError)
-	res.Header().Set("Content-Type", "text/plain; charset=utf-8")
-	_, _ = res.Write([]byte("500 internal server error"))
+	writeError(req, res, http.StatusInternalServerError, "500 internal server error")
 }
@@ -3,6 +3,9 @@ package expressgo
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"html/template"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -49,6 +52,40 @@ func TestDefaultErrorHandler(t *testing.T) {
 	})
 }
 
+// TestDefaultErrorHandlersMatchOuterError verifies that the default handlers match the outermost *e.Error only,
+// as they did before e.Error could be unwrapped
+func TestDefaultErrorHandlersMatchOuterError(t *testing.T) {
+	r := NewRouter()
+	r.Get("/x", func(_ *Request, _ *Response) error {
+		return e.NewError(http.StatusInternalServerError, e.ErrorTypeNotFound)
+	})
+
+	r.Get("/wrapped", func(_ *Request, _ *Response) error {
+		return fmt.Errorf("load user: %w", e.ErrorTypeNotFound)
+	})
+	r.Get("/derived", func(_ *Request, _ *Response) error {
+		return e.ErrorTypeNotFound.WithDetail("no such user")
+	})
+
+	tests := []struct {
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{"/x", http.StatusInternalServerError, "Not Found"},
+		{"/wrapped", http.StatusNotFound, "Cannot find the path \"/wrapped\""},
+		{"/derived", http.StatusNotFound, "Cannot find the path \"/derived\""},
+	}
+
+	for _, tc := range tests {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		assert.Equal(t, tc.expectedCode, rr.Code, "Unexpected status for %s", tc.path)
+		assert.Equal(t, tc.expectedBody, rr.Body.String(), "Unexpected body for %s", tc.path)
+	}
+}
+
 // The custom error handling function for 404 errors
 func JSONNotFoundErrorHandler(err error, req *Request, res *Response, next func(error)) {
 	var er *e.Error
@@ -160,3 +197,124 @@ func TestCustomErrorHandling(t *testing.T) {
 		})
 	}
 }
+
+func TestNegotiatedErrorHandlers(t *testing.T) {
+	r := NewRouter()
+	r.Handle("/test", http.MethodGet, HandlerFunc(func(_ *Request, res *Response) error {
+		_, _ = res.Write([]byte("OK"))
+		return nil
+	}))
+	r.Get("/encoded", JSONBodyEncoder, func(_ *Request, res *Response) error {
+		res.UseEncoderDecorator(func(next Encoder) Encoder {
+			return func(w http.ResponseWriter, obj any) error {
+				if w.Header().Get("Content-Type") == "application/json" {
+					_, _ = w.Write([]byte(`{"envelope":true}`))
+					return nil
+				}
+				return next(w, obj)
+			}
+		})
+		return e.ErrorTypeUnauthorized
+	})
+
+	tests := []struct {
+		name                string
+		method              string
+		path                string
+		accept              string
+		expectedCode        int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			"404 json", http.MethodGet, "/missing", "application/json", http.StatusNotFound, "application/json",
+			`{"status":404,"title":"Not Found","message":"Cannot find the path \"/missing\"","path":"/missing"}` + "\n",
+		},
+		{
+			"405 xml", http.MethodPost, "/test", "application/xml", http.StatusMethodNotAllowed, "application/xml",
+			`<error><status>405</status><title>Method Not Allowed</title><message>Method &#34;POST&#34; is not allowed on path &#34;test&#34;</message><path>/test</path></error>`,
+		},
+		{
+			"404 html", http.MethodGet, "/<missing>", "text/html,application/xhtml+xml,*/*;q=0.8", http.StatusNotFound, "text/html; charset=utf-8",
+			"<!DOCTYPE html>\n<html>\n<head><title>404 Not Found</title></head>\n<body>\n<h1>404 Not Found</h1>\n" +
+				"<p>Cannot find the path &#34;/&lt;missing&gt;&#34;</p>\n</body>\n</html>\n",
+		},
+		{
+			"404 text", http.MethodGet, "/missing", "text/plain", http.StatusNotFound, "text/plain; charset=utf-8",
+			`Cannot find the path "/missing"`,
+		},
+		{
+			"404 unacceptable", http.MethodGet, "/missing", "image/png", http.StatusNotFound, "text/plain; charset=utf-8",
+			`Cannot find the path "/missing"`,
+		},
+		{
+			"401 encoder chain", http.MethodGet, "/encoded", "application/json", http.StatusUnauthorized, "application/json",
+			`{"envelope":true}`,
+		},
+		{
+			"401 response format", http.MethodGet, "/encoded", "*/*", http.StatusUnauthorized, "application/json",
+			`{"envelope":true}`,
+		},
+		{
+			"401 no accept", http.MethodGet, "/encoded", "", http.StatusUnauthorized, "application/json",
+			`{"envelope":true}`,
+		},
+		{
+			"401 accepted over response format", http.MethodGet, "/encoded", "text/plain", http.StatusUnauthorized, "text/plain; charset=utf-8",
+			"401 unauthorized",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Accept", tt.accept)
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, tt.expectedContentType, rr.Header().Get("Content-Type"))
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
+			assert.Equal(t, "Accept", rr.Header().Get("Vary"))
+		})
+	}
+}
+
+func TestErrorTemplates(t *testing.T) {
+	r := NewRouter()
+	r.SetErrorTemplate("text/html", NewErrorTemplate(template.Must(template.New("").Parse(`<h1>{{.Status}}</h1>`))))
+	r.SetErrorTemplate("application/vnd.api+json", func(w io.Writer, data ErrorData) error {
+		_, err := fmt.Fprintf(w, `{"errors":[{"status":"%d"}]}`, data.Status)
+		return err
+	})
+
+	admin := r.Group("/admin")
+	admin.SetErrorTemplate("text/html", func(w io.Writer, data ErrorData) error {
+		_, err := fmt.Fprintf(w, "<p>admin: %s</p>", data.Title)
+		return err
+	})
+	admin.Get("/fail", func(*Request, *Response) error { return e.ErrorTypeUnauthorized })
+
+	tests := []struct {
+		path                string
+		accept              string
+		expectedContentType string
+		expectedBody        string
+	}{
+		{"/missing", "text/html", "text/html; charset=utf-8", "<h1>404</h1>"},
+		{"/missing", "application/vnd.api+json", "application/vnd.api+json", `{"errors":[{"status":"404"}]}`},
+		{"/admin/fail", "text/html", "text/html; charset=utf-8", "<p>admin: Unauthorized</p>"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Header.Set("Accept", tt.accept)
+		rr := httptest.NewRecorder()
+
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, tt.expectedContentType, rr.Header().Get("Content-Type"), "Unexpected Content-Type for %s", tt.path)
+		assert.Equal(t, tt.expectedBody, rr.Body.String(), "Unexpected body for %s", tt.path)
+	}
+}
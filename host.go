@@ -0,0 +1,141 @@
+package expressgo
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// hostRouter is a router serving the requests whose Host matches its pattern
+type hostRouter struct {
+	pattern string
+	labels  []hostLabel
+	router  *Router
+
+	// scope matches the paths under the prefix of the group the host router was created on, if any
+	scope *node
+}
+
+// hostLabel is one dot separated label of a host pattern, either literal or a parameter
+type hostLabel struct {
+	literal    string
+	name       string
+	constraint *constraint
+}
+
+// Host returns a sub-router serving the requests whose Host header matches pattern.
+//
+// The pattern is a host name whose labels can be parameters written as in route
+// paths, e.g. "{tenant}.example.com" or "{tenant:slug}.example.com". Their values
+// are available through Request.Param. Host routers are tried in registration
+// order, and requests matching none of them are served by the routes of this
+// router. The host router of a group only serves the requests under the group's
+// prefix. The host router runs the middleware of this router and reports errors
+// through its HandleError. Host panics if the pattern is invalid.
+func (rt *Router) Host(pattern string) *Router {
+	h := &hostRouter{
+		pattern: strings.ToLower(pattern),
+		router: &Router{
+			parent: rt,
+			prefix: rt.prefix,
+			table:  newRouteTable(),
+		},
+	}
+	h.router.table.options = rt.table.options
+	if rt.prefix != "" {
+		h.scope = &node{}
+		for _, p := range []string{rt.prefix, rt.prefix + "/*"} {
+			n := h.scope.insert(p)
+			n.leaves = append(n.leaves, newLeaf(p))
+		}
+	}
+
+	for _, label := range strings.Split(h.pattern, ".") {
+		if label == "" || (label[0] != ':' && label[0] != '{') {
+			h.labels = append(h.labels, hostLabel{literal: label})
+			continue
+		}
+		name, c, err := parseParam(label)
+		if err != nil {
+			panic(fmt.Sprintf("expressgo: host pattern %q: label %q: %v", pattern, label, err))
+		}
+		h.labels = append(h.labels, hostLabel{name: name, constraint: c})
+	}
+
+	rt.table.update(func(s *routeSnapshot) {
+		s.hosts = append(s.hosts[:len(s.hosts):len(s.hosts)], h)
+	})
+	return h.router
+}
+
+// matchHost returns the first host router matching host and the request path, along with the captured parameters
+func (t *routeTable) matchHost(host string, path string) (*Router, []pathParam) {
+	hosts := t.routes().hosts
+	if len(hosts) == 0 {
+		return nil, nil
+	}
+
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	labels := strings.Split(strings.ToLower(strings.TrimSuffix(host, ".")), ".")
+
+	for _, h := range hosts {
+		if h.scope != nil {
+			var params []pathParam
+			if !h.scope.match(t.routePath(path), t.options.caseInsensitive, &params, func(*leaf) bool { return true }) {
+				continue
+			}
+		}
+		if params, ok := h.match(labels); ok {
+			return h.router, params
+		}
+	}
+	return nil, nil
+}
+
+// match reports whether the host labels fit the pattern and returns the captured parameters
+func (h *hostRouter) match(labels []string) ([]pathParam, bool) {
+	if len(labels) != len(h.labels) {
+		return nil, false
+	}
+
+	var params []pathParam
+	for i, l := range h.labels {
+		if l.name == "" {
+			if l.literal != labels[i] {
+				return nil, false
+			}
+			continue
+		}
+		if labels[i] == "" || !l.constraint.match(labels[i]) {
+			return nil, false
+		}
+		params = append(params, pathParam{key: l.name, value: labels[i]})
+	}
+	return params, true
+}
+
+// expand writes the host pattern to b with its parameters substituted from params.
+// Substituted parameters are deleted from params.
+func (h *hostRouter) expand(b *strings.Builder, params map[string]string) error {
+	for i, l := range h.labels {
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		if l.name == "" {
+			b.WriteString(l.literal)
+			continue
+		}
+		value, ok := params[l.name]
+		if !ok || value == "" {
+			return fmt.Errorf("missing host parameter %q", l.name)
+		}
+		if strings.Contains(value, ".") || !l.constraint.match(value) {
+			return fmt.Errorf("host parameter %q does not match %q", l.name, h.pattern)
+		}
+		delete(params, l.name)
+		b.WriteString(value)
+	}
+	return nil
+}
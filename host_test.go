@@ -0,0 +1,138 @@
+package expressgo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostRouting(t *testing.T) {
+	route := NewRouter()
+	route.Use(tagMiddleware("root"))
+	route.Get("/", func(_ *Request, res *Response) error {
+		fmt.Fprint(res, "default home")
+		return nil
+	})
+
+	api := route.Host("api.example.com")
+	api.Get("/", func(_ *Request, res *Response) error {
+		fmt.Fprint(res, "api home")
+		return nil
+	})
+
+	tenant := route.Host("{tenant:slug}.example.com")
+	tenant.Get("/users/:id", func(req *Request, res *Response) error {
+		fmt.Fprintf(res, "tenant %s user %s", req.Param("tenant"), req.Param("id"))
+		return nil
+	})
+
+	tests := []struct {
+		host          string
+		path          string
+		expectedCode  int
+		expectedBody  string
+		expectedTrace []string
+	}{
+		{"api.example.com", "/", http.StatusOK, "api home", []string{"root"}},
+		{"API.Example.com:8080", "/", http.StatusOK, "api home", []string{"root"}},
+		{"acme.example.com", "/users/42", http.StatusOK, "tenant acme user 42", []string{"root"}},
+		{"acme.example.com", "/", http.StatusNotFound, "Cannot find the path \"/\"", nil},
+		{"example.com", "/", http.StatusOK, "default home", []string{"root"}},
+		{"a.b.example.com", "/users/42", http.StatusNotFound, "Cannot find the path \"/users/42\"", nil},
+		{"bad_tenant.example.com", "/users/42", http.StatusNotFound, "Cannot find the path \"/users/42\"", nil},
+	}
+
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		req.Host = tc.host
+		rr := httptest.NewRecorder()
+
+		route.ServeHTTP(rr, req)
+
+		assert.Equal(t, tc.expectedCode, rr.Code, "Unexpected status code for %s%s", tc.host, tc.path)
+		assert.Equal(t, tc.expectedBody, rr.Body.String(), "Unexpected response body for %s%s", tc.host, tc.path)
+		assert.Equal(t, tc.expectedTrace, rr.Header().Values("X-Trace"), "Unexpected middleware for %s%s", tc.host, tc.path)
+	}
+}
+
+func TestGroupHostRouting(t *testing.T) {
+	route := NewRouter()
+	route.Get("/health", func(_ *Request, res *Response) error {
+		fmt.Fprint(res, "ok")
+		return nil
+	})
+
+	api := route.Group("/api/:version", tagMiddleware("api"))
+	api.Host("{tenant}.example.com").Get("/users", func(req *Request, res *Response) error {
+		fmt.Fprintf(res, "tenant %s %s users", req.Param("tenant"), req.Param("version"))
+		return nil
+	})
+
+	tests := []struct {
+		path          string
+		expectedCode  int
+		expectedBody  string
+		expectedTrace []string
+	}{
+		{"/health", http.StatusOK, "ok", nil},
+		{"/api/v1/users", http.StatusOK, "tenant acme v1 users", []string{"api"}},
+		{"/api/v1/missing", http.StatusNotFound, "Cannot find the path \"/api/v1/missing\"", nil},
+		{"/apis", http.StatusNotFound, "Cannot find the path \"/apis\"", nil},
+	}
+
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		req.Host = "acme.example.com"
+		rr := httptest.NewRecorder()
+
+		route.ServeHTTP(rr, req)
+
+		assert.Equal(t, tc.expectedCode, rr.Code, "Unexpected status code for %s", tc.path)
+		assert.Equal(t, tc.expectedBody, rr.Body.String(), "Unexpected response body for %s", tc.path)
+		assert.Equal(t, tc.expectedTrace, rr.Header().Values("X-Trace"), "Unexpected middleware for %s", tc.path)
+	}
+}
+
+func TestHostRoutingErrors(t *testing.T) {
+	route := NewRouter()
+	route.RegisterErrorHandler(func(err error, _ *Request, res *Response, _ func(error)) {
+		res.WriteHeader(http.StatusTeapot)
+		fmt.Fprintf(res, "handled: %v", err)
+	})
+
+	route.Host("{tenant}.example.com").Get("/", homeHandler)
+
+	req := httptest.NewRequest(http.MethodGet, "/missing", nil)
+	req.Host = "acme.example.com"
+	rr := httptest.NewRecorder()
+
+	route.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusTeapot, rr.Code)
+	assert.Equal(t, "handled: Not Found", rr.Body.String())
+}
+
+func TestHostRoutes(t *testing.T) {
+	route := NewRouter()
+	route.Get("/", homeHandler)
+	route.Host("{tenant}.example.com").Get("/users/:id", homeHandler).Name("tenant-user")
+
+	assert.Equal(t, []RouteInfo{
+		{Method: "GET", Path: "/", Middlewares: nil},
+		{Host: "{tenant}.example.com", Method: "GET", Path: "/users/:id", Name: "tenant-user", Middlewares: nil},
+	}, route.Routes())
+
+	u, err := route.URL("tenant-user", "tenant", "acme", "id", "7", "tab", "posts")
+	assert.NoError(t, err)
+	assert.Equal(t, "//acme.example.com/users/7?tab=posts", u)
+
+	_, err = route.URL("tenant-user", "id", "7")
+	assert.Error(t, err)
+	_, err = route.URL("tenant-user", "tenant", "acme.evil", "id", "7")
+	assert.Error(t, err)
+
+	assert.Panics(t, func() { route.Host("{tenant:[a-z}.example.com") })
+}
@@ -87,8 +87,8 @@ func TestRegisterHandlerJSON(t *testing.T) {
 		router.ServeHTTP(rr, req)
 
 		assert.Equal(t, http.StatusBadRequest, rr.Code, "Expected status BadRequest")
-		assert.Equal(t, "text/plain; charset=utf-8", rr.Header().Get("Content-Type"), "Expected Content-Type text/plain")
-		assert.Equal(t, "Duplicate email", rr.Body.String(), "Response body mismatch")
+		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"), "Expected Content-Type application/json")
+		assert.JSONEq(t, `{"status": 400, "title": "Bad Request", "message": "Duplicate email", "path": "/register"}`, rr.Body.String(), "Response body mismatch")
 	})
 
 	t.Run("register fail: invalid format", func(t *testing.T) {
@@ -100,7 +100,7 @@ func TestRegisterHandlerJSON(t *testing.T) {
 		router.ServeHTTP(rr, req)
 
 		assert.Equal(t, http.StatusBadRequest, rr.Code, "Expected status BadRequest")
-		assert.Equal(t, "text/plain; charset=utf-8", rr.Header().Get("Content-Type"), "Expected Content-Type text/plain")
-		assert.Equal(t, "Registration's format incorrect.", rr.Body.String(), "Response body mismatch")
+		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"), "Expected Content-Type application/json")
+		assert.JSONEq(t, `{"status": 400, "title": "Bad Request", "message": "Registration's format incorrect.", "path": "/register"}`, rr.Body.String(), "Response body mismatch")
 	})
 }
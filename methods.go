@@ -0,0 +1,101 @@
+package expressgo
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// methodAll is the method key of routes registered with All
+const methodAll = ""
+
+// Get registers a route for GET requests.
+//
+// The last of handlers is the route handler, which may be a Handler, a
+// func(*Request, *Response) error or an http.Handler. Any values before it are
+// route middleware, given as Middleware, AroundMiddleware or functions of either
+// signature, which run in order after the router middleware and only for this route:
+//
+//	router.Get("/users/:id", loadUser, checkOwner, func(req *Request, res *Response) error {
+//		...
+//	})
+func (rt *Router) Get(path string, handlers ...any) *Route {
+	return rt.Handle(path, http.MethodGet, handlers...)
+}
+
+// Head registers a route for HEAD requests, see Get
+func (rt *Router) Head(path string, handlers ...any) *Route {
+	return rt.Handle(path, http.MethodHead, handlers...)
+}
+
+// Post registers a route for POST requests, see Get
+func (rt *Router) Post(path string, handlers ...any) *Route {
+	return rt.Handle(path, http.MethodPost, handlers...)
+}
+
+// Put registers a route for PUT requests, see Get
+func (rt *Router) Put(path string, handlers ...any) *Route {
+	return rt.Handle(path, http.MethodPut, handlers...)
+}
+
+// Patch registers a route for PATCH requests, see Get
+func (rt *Router) Patch(path string, handlers ...any) *Route {
+	return rt.Handle(path, http.MethodPatch, handlers...)
+}
+
+// Delete registers a route for DELETE requests, see Get
+func (rt *Router) Delete(path string, handlers ...any) *Route {
+	return rt.Handle(path, http.MethodDelete, handlers...)
+}
+
+// Options registers a route for OPTIONS requests, see Get
+func (rt *Router) Options(path string, handlers ...any) *Route {
+	return rt.Handle(path, http.MethodOptions, handlers...)
+}
+
+// All registers a route matching every request method that has no route of its own, see Get
+func (rt *Router) All(path string, handlers ...any) *Route {
+	return rt.Handle(path, methodAll, handlers...)
+}
+
+// splitHandlers converts the handlers argument of Handle into the route handler and its middleware.
+// It panics when a value has an unsupported type.
+func splitHandlers(handlers []any) (Handler, []link) {
+	if len(handlers) == 0 {
+		panic("expressgo: route registered without a handler")
+	}
+
+	middlewares := make([]link, 0, len(handlers)-1)
+	for _, m := range handlers[:len(handlers)-1] {
+		middlewares = append(middlewares, toLink(m))
+	}
+
+	return toHandler(handlers[len(handlers)-1]), middlewares
+}
+
+// toHandler converts a route handler value into a Handler
+func toHandler(h any) Handler {
+	switch h := h.(type) {
+	case Handler:
+		return h
+	case func(*Request, *Response) error:
+		return HandlerFunc(h)
+	case http.Handler:
+		return WrapHandler(h)
+	}
+	panic(fmt.Sprintf("expressgo: unsupported handler type %T", h))
+}
+
+// toLink converts a route middleware value into a chain link
+func toLink(m any) link {
+	switch m := m.(type) {
+	case Middleware:
+		return link{middleware: m}
+	case func(*Request, *Response, func()) error:
+		return link{middleware: m}
+	case AroundMiddleware:
+		return link{around: m}
+	case func(*Request, *Response, func() error) error:
+		return link{around: m}
+	}
+	panic(fmt.Sprintf("expressgo: unsupported middleware type %T", m))
+}
@@ -0,0 +1,108 @@
+package expressgo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMethodShortcuts verifies that each shortcut registers its route for the matching method
+func TestMethodShortcuts(t *testing.T) {
+	route := NewRouter()
+
+	respond := func(body string) func(*Request, *Response) error {
+		return func(_ *Request, res *Response) error {
+			fmt.Fprint(res, body)
+			return nil
+		}
+	}
+
+	route.Get("/books", respond("list"))
+	route.Post("/books", respond("create"))
+	route.Put("/books/:id", respond("replace"))
+	route.Patch("/books/:id", respond("update"))
+	route.Delete("/books/:id", respond("delete"))
+	route.Options("/books", respond("options"))
+	route.Head("/books/:id", HandlerFunc(func(_ *Request, res *Response) error {
+		res.Header().Set("X-Head", "yes")
+		return nil
+	}))
+	route.All("/any", respond("any"))
+
+	tests := []struct {
+		method       string
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{http.MethodGet, "/books", http.StatusOK, "list"},
+		{http.MethodPost, "/books", http.StatusOK, "create"},
+		{http.MethodPut, "/books/1", http.StatusOK, "replace"},
+		{http.MethodPatch, "/books/1", http.StatusOK, "update"},
+		{http.MethodDelete, "/books/1", http.StatusOK, "delete"},
+		{http.MethodOptions, "/books", http.StatusOK, "options"},
+		{http.MethodHead, "/books/1", http.StatusOK, ""},
+		{http.MethodGet, "/any", http.StatusOK, "any"},
+		{http.MethodPatch, "/any", http.StatusOK, "any"},
+		{http.MethodDelete, "/books", http.StatusMethodNotAllowed, "Method \"DELETE\" is not allowed on path \"books\""},
+	}
+
+	for _, tc := range tests {
+		rr := httptest.NewRecorder()
+		route.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, nil))
+
+		assert.Equal(t, tc.expectedCode, rr.Code, "Unexpected status code for %s %s", tc.method, tc.path)
+		assert.Equal(t, tc.expectedBody, rr.Body.String(), "Unexpected response body for %s %s", tc.method, tc.path)
+	}
+}
+
+// TestRouteMiddleware verifies that route middleware runs after the router middleware and only for its route
+func TestRouteMiddleware(t *testing.T) {
+	route := NewRouter()
+	route.Use(tagMiddleware("global"))
+
+	plain := func(_ *Request, res *Response, next func()) error {
+		res.Header().Add("X-Trace", "plain")
+		next()
+		return nil
+	}
+
+	route.Get("/guarded", tagMiddleware("route"), plain, func(_ *Request, res *Response) error {
+		fmt.Fprint(res, "guarded")
+		return nil
+	})
+	route.Get("/open", func(_ *Request, res *Response) error {
+		fmt.Fprint(res, "open")
+		return nil
+	})
+	route.Handle("/checked", http.MethodGet, testMiddleware, HandlerFunc(func(_ *Request, res *Response) error {
+		fmt.Fprint(res, "checked")
+		return nil
+	}))
+
+	rr := httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/guarded", nil))
+	assert.Equal(t, "guarded", rr.Body.String())
+	assert.Equal(t, []string{"global", "route", "plain"}, rr.Header().Values("X-Trace"))
+
+	rr = httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/open", nil))
+	assert.Equal(t, "open", rr.Body.String())
+	assert.Equal(t, []string{"global"}, rr.Header().Values("X-Trace"))
+
+	rr = httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/checked", nil))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
+// TestHandleRejectsInvalidHandlers verifies that registering unsupported values panics
+func TestHandleRejectsInvalidHandlers(t *testing.T) {
+	route := NewRouter()
+
+	assert.Panics(t, func() { route.Get("/none") })
+	assert.Panics(t, func() { route.Get("/string", "not a handler") })
+	assert.Panics(t, func() { route.Get("/middleware", homeHandler, homeHandler) })
+}
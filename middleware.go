@@ -1,4 +1,190 @@
 package expressgo
 
+import "errors"
+
 // Middleware is a function that is called before the handler
 type Middleware func(req *Request, res *Response, next func()) error
+
+// AroundMiddleware is a middleware whose next returns the error of the
+// middleware and handler after it. It can act on that error after the handler
+// has run, for example to roll back a transaction, log a failure or map the
+// error to another one. The error it returns replaces the downstream error;
+// returning nil swallows it.
+//
+//	func tx(req *Request, res *Response, next func() error) error {
+//		if err := next(); err != nil {
+//			rollback()
+//			return err
+//		}
+//		return commit()
+//	}
+type AroundMiddleware func(req *Request, res *Response, next func() error) error
+
+// Around adapts the middleware to an AroundMiddleware
+func (m Middleware) Around() AroundMiddleware {
+	return func(req *Request, res *Response, next func() error) error {
+		var err error
+		if e := m(req, res, func() { err = next() }); e != nil {
+			return e
+		}
+		return err
+	}
+}
+
+// link is one middleware of a chain, in either of the two forms
+type link struct {
+	middleware Middleware
+	around     AroundMiddleware
+}
+
+// links converts middleware to chain links
+func links(middlewares []Middleware) []link {
+	l := make([]link, len(middlewares))
+	for i, mw := range middlewares {
+		l[i] = link{middleware: mw}
+	}
+	return l
+}
+
+// fn returns the function of the link
+func (l link) fn() any {
+	if l.around != nil {
+		return l.around
+	}
+	return l.middleware
+}
+
+// chain is the middleware chain of an endpoint, compiled once and reused for
+// every request until middleware is added to one of the routers or the route it
+// depends on.
+type chain struct {
+	generation uint64
+	links      []link
+	handler    Handler
+	around     bool // whether any link is an AroundMiddleware
+}
+
+// ServeHTTP runs the middleware in order, each continuing to the next one or to
+// the handler when it calls next. A Middleware returns its own error if it
+// fails, or else the error of the last call of next. An AroundMiddleware
+// returns its own error only.
+func (c *chain) ServeHTTP(req *Request, res *Response) error {
+	if len(c.links) == 0 {
+		err := c.handler.ServeHTTP(req, res)
+		if err != nil && errors.Is(err, ErrNextRoute) {
+			return c.newCall(req, res).nextRoute(0, err)
+		}
+		return err
+	}
+	return c.newCall(req, res).run(0)
+}
+
+// newCall starts running the chain for a request
+func (c *chain) newCall(req *Request, res *Response) *chainCall {
+	call := &chainCall{chain: c, req: req, res: res}
+	call.next = call.proceed
+	if c.around {
+		call.nextErr = call.proceedErr
+	}
+	return call
+}
+
+// chainCall is the state of one request going through a chain. A single next
+// function is shared by all middleware, so the request allocates the same
+// regardless of the length of the chain.
+type chainCall struct {
+	chain   *chain
+	req     *Request
+	res     *Response
+	next    func()
+	nextErr func() error
+
+	// current is the index of the middleware whose next is called
+	current int
+	// err is the error returned downstream by the last call of next
+	err error
+
+	// pending is the route to pass the request on to, once ErrNextRoute is
+	// returned up to the last middleware it shares with the current route
+	pending *routeCandidate
+	// exhausted is set when no route is left to pass the request on to
+	exhausted bool
+}
+
+// run calls the middleware at index i, or the handler past the last one, and
+// passes the request on to the next route if it returns ErrNextRoute
+func (c *chainCall) run(i int) error {
+	err := c.call(i)
+	if err != nil && errors.Is(err, ErrNextRoute) {
+		return c.nextRoute(i, err)
+	}
+	return err
+}
+
+// nextRoute continues with the next route matching the request after the
+// middleware or handler at index i returned ErrNextRoute. The error is
+// returned up to the last middleware the next route shares with the current
+// one, where the chain of the next route takes over, so that the shared
+// middleware runs only once.
+func (c *chainCall) nextRoute(i int, err error) error {
+	for errors.Is(err, ErrNextRoute) {
+		if c.pending == nil && !c.exhausted {
+			c.pending = c.req.nextRoute()
+			c.exhausted = c.pending == nil
+		}
+		if c.pending == nil || c.pending.shared < i {
+			return err
+		}
+
+		next := c.pending
+		c.pending = nil
+		switch routing := &c.req.routing; {
+		case next.headFromGet && !routing.headFromGet:
+			c.res.ResponseWriter = headResponseWriter{c.res.ResponseWriter}
+		case !next.headFromGet && routing.headFromGet:
+			if w, ok := c.res.ResponseWriter.(headResponseWriter); ok {
+				c.res.ResponseWriter = w.ResponseWriter
+			}
+		}
+		c.req.routing.endpoint, c.req.routing.headFromGet = next.endpoint, next.headFromGet
+		c.chain = next.endpoint.chain()
+		c.nextErr = c.proceedErr
+
+		err = c.call(i)
+	}
+	return err
+}
+
+// call calls the middleware at index i, or the handler past the last one
+func (c *chainCall) call(i int) error {
+	if i == len(c.chain.links) {
+		return c.chain.handler.ServeHTTP(c.req, c.res)
+	}
+
+	c.current = i
+	l := c.chain.links[i]
+	if l.around != nil {
+		return l.around(c.req, c.res, c.nextErr)
+	}
+
+	c.err = nil
+	if err := l.middleware(c.req, c.res, c.next); err != nil {
+		return err
+	}
+	return c.err
+}
+
+// proceed is the next function of the middleware at index current
+func (c *chainCall) proceed() {
+	i := c.current
+	err := c.run(i + 1)
+	c.current, c.err = i, err
+}
+
+// proceedErr is the next function of the AroundMiddleware at index current
+func (c *chainCall) proceedErr() error {
+	i := c.current
+	err := c.run(i + 1)
+	c.current = i
+	return err
+}
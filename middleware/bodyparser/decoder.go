@@ -2,9 +2,9 @@
 package bodyparser
 
 import (
-	"encoding/json"
-	"encoding/xml"
 	"io"
+
+	"github.com/mikaeloduh/expressgo"
 )
 
 // JSONDecoder decodes JSON data from an io.Reader into the provided value.
@@ -18,7 +18,7 @@ import (
 // Returns:
 //   - error: Any error encountered during the decoding process
 func JSONDecoder(r io.Reader, v any) error {
-	return json.NewDecoder(r).Decode(v)
+	return expressgo.JSONDecoder(r, v)
 }
 
 // XMLDecoder decodes XML data from an io.Reader into the provided value.
@@ -32,5 +32,5 @@ func JSONDecoder(r io.Reader, v any) error {
 // Returns:
 //   - error: Any error encountered during the decoding process
 func XMLDecoder(r io.Reader, v any) error {
-	return xml.NewDecoder(r).Decode(v)
+	return expressgo.XMLDecoder(r, v)
 }
@@ -0,0 +1,222 @@
+package expressgo
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mikaeloduh/expressgo/e"
+)
+
+func TestMiddlewareChainErrors(t *testing.T) {
+	errHandler := errors.New("handler failed")
+	errMiddleware := errors.New("middleware failed")
+
+	var calls int
+	handler := HandlerFunc(func(*Request, *Response) error {
+		calls++
+		return errHandler
+	})
+	pass := func(_ *Request, _ *Response, next func()) error {
+		next()
+		return nil
+	}
+
+	tests := []struct {
+		name        string
+		middlewares []Middleware
+		expectedErr error
+		calls       int
+	}{
+		{"downstream error", []Middleware{pass, pass}, errHandler, 1},
+		{"own error", []Middleware{pass, func(_ *Request, _ *Response, next func()) error {
+			next()
+			return errMiddleware
+		}}, errMiddleware, 1},
+		{"no next", []Middleware{pass, func(*Request, *Response, func()) error { return nil }}, nil, 0},
+		{"next twice", []Middleware{func(_ *Request, _ *Response, next func()) error {
+			next()
+			next()
+			return nil
+		}, pass}, errHandler, 2},
+		{"long chain", []Middleware{pass, pass, pass, pass, pass, pass, pass, pass, pass, pass}, errHandler, 1},
+	}
+
+	for _, tc := range tests {
+		calls = 0
+		c := NewRouter().compileChain(links(tc.middlewares), handler)
+		err := c.ServeHTTP(NewRequest(httptest.NewRequest(http.MethodGet, "/", nil)), NewResponse(httptest.NewRecorder()))
+		assert.Equal(t, tc.expectedErr, err, "Unexpected error for %s", tc.name)
+		assert.Equal(t, tc.calls, calls, "Unexpected handler calls for %s", tc.name)
+	}
+}
+
+func TestAroundMiddleware(t *testing.T) {
+	errNotFound := errors.New("not found")
+
+	var seen []error
+	route := NewRouter()
+	route.UseAround(func(req *Request, res *Response, next func() error) error {
+		err := next()
+		seen = append(seen, err)
+		return err
+	})
+	route.Use(tagMiddleware("plain"))
+	route.Get("/fail", func(*Request, *Response) error { return errNotFound })
+	route.Get("/mapped", func(req *Request, res *Response, next func() error) error {
+		if err := next(); errors.Is(err, errNotFound) {
+			return e.ErrorTypeNotFound
+		}
+		return nil
+	}, func(*Request, *Response) error { return errNotFound })
+	route.Get("/swallowed", AroundMiddleware(func(req *Request, res *Response, next func() error) error {
+		_ = next()
+		return nil
+	}), func(*Request, *Response) error { return errNotFound })
+
+	rr := httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/fail", nil))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, []string{"plain"}, rr.Header().Values("X-Trace"))
+
+	rr = httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/mapped", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/swallowed", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	assert.Equal(t, []error{errNotFound, e.ErrorTypeNotFound, nil}, seen)
+}
+
+func TestMiddlewareAround(t *testing.T) {
+	errHandler := errors.New("handler failed")
+	around := tagMiddleware("plain").Around()
+
+	var called bool
+	err := around(nil, &Response{ResponseWriter: httptest.NewRecorder()}, func() error {
+		called = true
+		return errHandler
+	})
+	assert.True(t, called)
+	assert.Equal(t, errHandler, err)
+}
+
+func TestMiddlewareChainRecompiled(t *testing.T) {
+	route := NewRouter()
+	api := route.Group("/api")
+	users := api.Route("/users")
+	users.Get(homeHandler)
+
+	serve := func() []string {
+		rr := httptest.NewRecorder()
+		route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/users", nil))
+		return rr.Header().Values("X-Trace")
+	}
+
+	assert.Empty(t, serve())
+
+	route.Use(tagMiddleware("root"))
+	assert.Equal(t, []string{"root"}, serve())
+
+	api.Use(tagMiddleware("api"))
+	assert.Equal(t, []string{"root", "api"}, serve())
+
+	users.Use(tagMiddleware("route"))
+	assert.Equal(t, []string{"root", "api", "route"}, serve())
+
+	route.Param("id", func(*Request, *Response, func(), string) error { return nil })
+	assert.Equal(t, []string{"root", "api", "route"}, serve())
+}
+
+func BenchmarkMiddlewareChain(b *testing.B) {
+	pass := func(_ *Request, _ *Response, next func()) error {
+		next()
+		return nil
+	}
+
+	around := func(_ *Request, _ *Response, next func() error) error {
+		return next()
+	}
+
+	for _, n := range []int{0, 5, 10} {
+		b.Run(fmt.Sprintf("%d", n), func(b *testing.B) {
+			route := NewRouter()
+			for i := 0; i < n; i++ {
+				route.Use(pass)
+			}
+			benchmarkServe(b, route, noopHandler)
+		})
+		b.Run(fmt.Sprintf("%d-around", n), func(b *testing.B) {
+			route := NewRouter()
+			for i := 0; i < n; i++ {
+				route.UseAround(around)
+			}
+			benchmarkServe(b, route, noopHandler)
+		})
+	}
+}
+
+// BenchmarkApplyMiddleware measures the router as it was before chains were
+// compiled, building the chain of middleware closures for every request
+func BenchmarkApplyMiddleware(b *testing.B) {
+	pass := func(_ *Request, _ *Response, next func()) error {
+		next()
+		return nil
+	}
+
+	for _, n := range []int{0, 5, 10} {
+		b.Run(fmt.Sprintf("%d", n), func(b *testing.B) {
+			middlewares := make([]Middleware, n)
+			for i := range middlewares {
+				middlewares[i] = pass
+			}
+			benchmarkServe(b, NewRouter(), func(req *Request, res *Response) error {
+				return applyMiddleware(middlewares, HandlerFunc(noopHandler)).ServeHTTP(req, res)
+			})
+		})
+	}
+}
+
+// applyMiddleware wraps the handler in the middleware the way the router did for every request
+func applyMiddleware(middlewares []Middleware, handler Handler) Handler {
+	h := handler
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		mw := middlewares[i]
+		currentHandler := h
+		h = HandlerFunc(func(r *Request, w *Response) error {
+			var err error
+			next := func() {
+				err = currentHandler.ServeHTTP(r, w)
+			}
+			if err := mw(r, w, next); err != nil {
+				return err
+			}
+			return err
+		})
+	}
+	return h
+}
+
+func noopHandler(*Request, *Response) error {
+	return nil
+}
+
+// benchmarkServe measures serving a parameter route of the router with the handler
+func benchmarkServe(b *testing.B, route *Router, handler HandlerFunc) {
+	route.Get("/users/:id", handler)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		route.ServeHTTP(rr, req)
+	}
+}
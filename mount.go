@@ -0,0 +1,84 @@
+package expressgo
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// mountContextKey is the context key under which a mounted request keeps its mount information
+type mountContextKey struct{}
+
+// mountInfo records where a request was before it was forwarded to a mounted handler
+type mountInfo struct {
+	originalURL *url.URL
+	basePath    string
+
+	// params are the parameters captured by the prefixes the request was mounted at
+	params []pathParam
+}
+
+// Mount forwards every request under prefix to handler, with the prefix stripped
+// from the request path. Handler may be another Router, which lets independently
+// built apps be composed into one server, or any http.Handler such as
+// http.FileServer.
+//
+// The request seen by the mounted handler keeps the original URL and the path it
+// was mounted at, available through Request.OriginalURL and Request.BaseURL.
+// Requests run the middleware of this router before reaching the handler.
+//
+// The prefix can have parameters, which a mounted Router makes available to its
+// handlers through Request.Param. Named routes of a mounted Router can be built
+// with the URL method of this router.
+func (rt *Router) Mount(prefix string, handler http.Handler) {
+	base := strings.TrimRight(rt.fullPath(prefix), "/")
+	ep := &endpoint{router: rt, handler: mountHandler(handler)}
+	rt.handle(routePath(base), methodAll, ep)
+	rt.handle(base+"/*", methodAll, ep)
+
+	if sub, ok := handler.(*Router); ok {
+		rt.table.update(func(s *routeSnapshot) {
+			s.mounts = append(s.mounts[:len(s.mounts):len(s.mounts)], mount{owner: rt, pattern: base, router: sub})
+		})
+	}
+}
+
+// mountHandler forwards requests to handler, stripping the part of the path matched by the mount route
+func mountHandler(handler http.Handler) HandlerFunc {
+	return func(req *Request, res *Response) error {
+		rest := req.Param("*")
+
+		base := strings.TrimSuffix(routePath(req.URL.Path), routePath(rest))
+		if base == "/" {
+			base = ""
+		}
+
+		stripped := "/" + rest
+		if rest != "" && strings.HasSuffix(req.URL.Path, "/") && !strings.HasSuffix(stripped, "/") {
+			stripped += "/"
+		}
+
+		info := &mountInfo{originalURL: req.URL, basePath: base}
+		for _, p := range req.params {
+			// the catch-all of the mount route holds the rest of the path, not a parameter of the prefix
+			if p.key != "*" {
+				info.params = append(info.params, p)
+			}
+		}
+		if parent, ok := req.Context().Value(mountContextKey{}).(*mountInfo); ok {
+			info.originalURL = parent.originalURL
+			info.basePath = parent.basePath + base
+		}
+
+		u := *req.URL
+		u.Path = stripped
+		u.RawPath = ""
+
+		r := req.Request.WithContext(context.WithValue(req.Context(), mountContextKey{}, info))
+		r.URL = &u
+
+		handler.ServeHTTP(res, r)
+		return nil
+	}
+}
@@ -0,0 +1,156 @@
+package expressgo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMountRouter verifies that a mounted router receives sub-paths with the prefix stripped
+func TestMountRouter(t *testing.T) {
+	admin := NewRouter()
+	admin.Get("/", func(req *Request, res *Response) error {
+		fmt.Fprintf(res, "admin home %s", req.URL.Path)
+		return nil
+	})
+	admin.Get("/users/:id", func(req *Request, res *Response) error {
+		fmt.Fprintf(res, "admin user %s at %s, original %s, base %s", req.Param("id"), req.URL.Path, req.OriginalURL().Path, req.BaseURL())
+		return nil
+	})
+
+	route := NewRouter()
+	route.Use(tagMiddleware("root"))
+	route.Mount("/admin", admin)
+
+	tests := []struct {
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{"/admin", http.StatusOK, "admin home /"},
+		{"/admin/", http.StatusOK, "admin home /"},
+		{"/admin/users/42", http.StatusOK, "admin user 42 at /users/42, original /admin/users/42, base /admin"},
+		{"/admin/missing", http.StatusNotFound, "Cannot find the path \"/missing\""},
+		{"/administrator", http.StatusNotFound, "Cannot find the path \"/administrator\""},
+	}
+
+	for _, tc := range tests {
+		rr := httptest.NewRecorder()
+		route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		assert.Equal(t, tc.expectedCode, rr.Code, "Unexpected status code for path %s", tc.path)
+		assert.Equal(t, tc.expectedBody, rr.Body.String(), "Unexpected response body for path %s", tc.path)
+	}
+
+	rr := httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/users/1", nil))
+	assert.Equal(t, []string{"root"}, rr.Header().Values("X-Trace"))
+}
+
+// TestMountNested verifies that nested mounts accumulate the base path and keep the outermost original URL
+func TestMountNested(t *testing.T) {
+	reports := NewRouter()
+	reports.Get("/:year", func(req *Request, res *Response) error {
+		fmt.Fprintf(res, "%s %s %s", req.Param("year"), req.BaseURL(), req.OriginalURL().Path)
+		return nil
+	})
+
+	admin := NewRouter()
+	admin.Mount("/reports", reports)
+
+	route := NewRouter()
+	route.Group("/api").Mount("/admin", admin)
+
+	rr := httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/admin/reports/2024", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "2024 /api/admin/reports /api/admin/reports/2024", rr.Body.String())
+}
+
+// TestMountHTTPHandler verifies that a standard http.Handler can be mounted
+func TestMountHTTPHandler(t *testing.T) {
+	files := fstest.MapFS{
+		"css/app.css": &fstest.MapFile{Data: []byte("body {}")},
+	}
+
+	route := NewRouter()
+	route.Mount("/static", http.FileServer(http.FS(files)))
+
+	rr := httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/static/css/app.css", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "body {}", rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/static/missing.css", nil))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestMountStreaming(t *testing.T) {
+	stream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "no flusher", http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte("data: 1\n\n"))
+		f.Flush()
+	})
+
+	sub := NewRouter()
+	sub.Get("/events", stream)
+
+	route := NewRouter()
+	route.Mount("/m", stream)
+	route.Mount("/sub", sub)
+
+	for _, path := range []string{"/m/x", "/sub/events"} {
+		rr := httptest.NewRecorder()
+		route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code, "Unexpected status for %s", path)
+		assert.True(t, rr.Flushed, "Expected %s to be flushed", path)
+		assert.Equal(t, "data: 1\n\n", rr.Body.String(), "Unexpected body for %s", path)
+	}
+}
+
+func TestMountParams(t *testing.T) {
+	projects := NewRouter()
+	projects.Get("/:project", func(req *Request, res *Response) error {
+		fmt.Fprintf(res, "org %s project %s", req.Param("org"), req.Param("project"))
+		return nil
+	})
+
+	orgs := NewRouter()
+	orgs.Get("/", func(req *Request, res *Response) error {
+		fmt.Fprintf(res, "org %s", req.Param("org"))
+		return nil
+	})
+	orgs.Mount("/projects", projects)
+
+	route := NewRouter()
+	route.Mount("/orgs/:org", orgs)
+
+	tests := []struct {
+		path         string
+		expectedBody string
+	}{
+		{"/orgs/acme", "org acme"},
+		{"/orgs/acme/projects/rocket", "org acme project rocket"},
+	}
+
+	for _, tc := range tests {
+		rr := httptest.NewRecorder()
+		route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code, "Unexpected status code for path %s", tc.path)
+		assert.Equal(t, tc.expectedBody, rr.Body.String(), "Unexpected response body for path %s", tc.path)
+	}
+}
@@ -0,0 +1,127 @@
+package expressgo
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Option configures a Router created by NewRouter
+type Option func(*routerOptions)
+
+// routerOptions holds the path matching policy of a router
+type routerOptions struct {
+	strictSlash           bool
+	redirectTrailingSlash bool
+	cleanPath             bool
+	caseInsensitive       bool
+}
+
+// WithStrictSlash makes "/users" and "/users/" distinct routes. By default
+// surrounding slashes are ignored and both paths match the same route.
+func WithStrictSlash() Option {
+	return func(o *routerOptions) {
+		o.strictSlash = true
+	}
+}
+
+// WithRedirectTrailingSlash redirects requests whose path differs from a
+// route only by a trailing slash to the path the route was registered with.
+// Without strict slashes, routes are registered without a trailing slash.
+func WithRedirectTrailingSlash() Option {
+	return func(o *routerOptions) {
+		o.redirectTrailingSlash = true
+	}
+}
+
+// WithCleanPath redirects requests whose path is not in canonical form, such
+// as "//users/../admin", to the path cleaned by path.Clean.
+func WithCleanPath() Option {
+	return func(o *routerOptions) {
+		o.cleanPath = true
+	}
+}
+
+// WithCaseInsensitive matches the static segments of routes regardless of case.
+// Parameter values keep the case of the request path.
+func WithCaseInsensitive() Option {
+	return func(o *routerOptions) {
+		o.caseInsensitive = true
+	}
+}
+
+// routePath normalises a registered or requested path according to the slash policy
+func (t *routeTable) routePath(p string) string {
+	if t.options.strictSlash {
+		return "/" + strings.TrimLeft(p, "/")
+	}
+	return routePath(p)
+}
+
+// lookup walks the routes matching path, see node.lookup
+func (t *routeTable) lookup(path string, params *[]pathParam, visit func(*leaf) bool) bool {
+	return t.routes().tree.match(path, t.options.caseInsensitive, params, visit)
+}
+
+// matches reports whether any route matches path
+func (t *routeTable) matches(path string) bool {
+	var params []pathParam
+	return t.lookup(path, &params, func(*leaf) bool { return true })
+}
+
+// redirectPath returns the canonical path a request for p should be redirected to, if any
+func (t *routeTable) redirectPath(p string) (string, bool) {
+	if t.options.cleanPath {
+		if cleaned := cleanPath(p); cleaned != p {
+			return cleaned, true
+		}
+	}
+
+	if !t.options.redirectTrailingSlash || p == "/" {
+		return "", false
+	}
+
+	if !t.options.strictSlash {
+		// the route matches, but was registered without the trailing slash
+		if trimmed := routePath(p); strings.HasSuffix(p, "/") && t.matches(trimmed) {
+			return trimmed, true
+		}
+		return "", false
+	}
+
+	if t.matches(p) {
+		return "", false
+	}
+	alternative := p + "/"
+	if strings.HasSuffix(p, "/") {
+		alternative = strings.TrimRight(p, "/")
+	}
+	if alternative != "" && t.matches(alternative) {
+		return alternative, true
+	}
+	return "", false
+}
+
+// cleanPath returns the canonical form of p, keeping its trailing slash
+func cleanPath(p string) string {
+	cleaned := path.Clean("/" + p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// redirect permanently redirects the request to path, keeping the mount prefix and the query string.
+// GET and HEAD requests get a 301, other methods a 308 so that clients keep the method and body.
+func redirect(req *Request, res *Response, path string) {
+	target := req.BaseURL() + path
+	if req.URL.RawQuery != "" {
+		target += "?" + req.URL.RawQuery
+	}
+
+	code := http.StatusPermanentRedirect
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		code = http.StatusMovedPermanently
+	}
+	http.Redirect(res, req.Request, target, code)
+}
@@ -0,0 +1,89 @@
+package expressgo
+
+// ParamHandler is called with the value of a path parameter before the
+// handlers of a route containing that parameter. Like a Middleware, it calls
+// next to continue handling the request, or returns an error to stop it.
+type ParamHandler func(req *Request, res *Response, next func(), value string) error
+
+// Param registers a handler for the named path parameter, typically to load
+// the resource it identifies once for every route using it:
+//
+//	router.Param("userId", func(req *Request, res *Response, next func(), id string) error {
+//		user, err := users.Find(id)
+//		if err != nil {
+//			return e.NewError(http.StatusNotFound, err)
+//		}
+//		req.Set("user", user)
+//		next()
+//		return nil
+//	})
+//
+// Param handlers apply to the routes of the router and its groups. They run
+// after the router middleware and before the route middleware, at most once per
+// request for a parameter value, in the order the parameters appear in the
+// request. Their errors are handled by HandleError.
+func (rt *Router) Param(name string, handler ParamHandler) {
+	if rt.paramHandlers == nil {
+		rt.paramHandlers = make(map[string][]ParamHandler)
+	}
+	rt.paramHandlers[name] = append(rt.paramHandlers[name], handler)
+	rt.changes++
+}
+
+// paramCall is a param handler to run for a parameter value of the request
+type paramCall struct {
+	handler ParamHandler
+	value   string
+}
+
+// hasParamHandlers reports whether the router or one of its parents has param handlers
+func (rt *Router) hasParamHandlers() bool {
+	for r := rt; r != nil; r = r.parent {
+		if len(r.paramHandlers) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// paramMiddleware runs the param handlers of the router and its parents for
+// the parameters of the request that were not handled yet
+func (rt *Router) paramMiddleware(req *Request, res *Response, next func()) error {
+	var calls []paramCall
+	for _, p := range req.params {
+		if value, ok := req.handledParams[p.key]; ok && value == p.value {
+			continue
+		}
+
+		var handlers []ParamHandler
+		for r := rt; r != nil; r = r.parent {
+			own := r.paramHandlers[p.key]
+			handlers = append(own[:len(own):len(own)], handlers...)
+		}
+		if len(handlers) == 0 {
+			continue
+		}
+
+		if req.handledParams == nil {
+			req.handledParams = make(map[string]string)
+		}
+		req.handledParams[p.key] = p.value
+		for _, h := range handlers {
+			calls = append(calls, paramCall{handler: h, value: p.value})
+		}
+	}
+
+	var call func(i int) error
+	call = func(i int) error {
+		if i == len(calls) {
+			next()
+			return nil
+		}
+		var err error
+		if e := calls[i].handler(req, res, func() { err = call(i + 1) }, calls[i].value); e != nil {
+			return e
+		}
+		return err
+	}
+	return call(0)
+}
@@ -0,0 +1,96 @@
+package expressgo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mikaeloduh/expressgo/e"
+)
+
+func TestParamHandler(t *testing.T) {
+	route := NewRouter()
+	route.Use(tagMiddleware("router"))
+	route.Param("id", func(req *Request, res *Response, next func(), id string) error {
+		res.Header().Add("X-Trace", "param "+id)
+		req.Set("user", "user-"+id)
+		next()
+		return nil
+	})
+	route.Get("/users/:id", tagMiddleware("route"), func(req *Request, res *Response) error {
+		user, ok := req.Get("user")
+		assert.True(t, ok)
+		_, _ = res.Write([]byte(user.(string)))
+		return nil
+	})
+	route.Get("/files/:name", homeHandler)
+
+	rr := httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "user-42", rr.Body.String())
+	assert.Equal(t, []string{"router", "param 42", "route"}, rr.Header().Values("X-Trace"))
+
+	// routes without the parameter do not run its handler
+	rr = httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/files/a", nil))
+	assert.Equal(t, []string{"router"}, rr.Header().Values("X-Trace"))
+}
+
+func TestParamHandlerOrder(t *testing.T) {
+	paramHandler := func(tag string) ParamHandler {
+		return func(req *Request, res *Response, next func(), value string) error {
+			res.Header().Add("X-Trace", tag+" "+value)
+			next()
+			return nil
+		}
+	}
+
+	route := NewRouter()
+	route.Param("postId", paramHandler("post"))
+	route.Param("id", paramHandler("user"))
+	api := route.Group("/api")
+	api.Param("id", paramHandler("api user"))
+	api.Get("/users/:id/posts/:postId", homeHandler)
+
+	rr := httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/users/1/posts/2", nil))
+	assert.Equal(t, []string{"user 1", "api user 1", "post 2"}, rr.Header().Values("X-Trace"))
+}
+
+func TestParamHandlerError(t *testing.T) {
+	var handled bool
+	route := NewRouter()
+	route.Param("id", func(req *Request, res *Response, next func(), id string) error {
+		return e.NewError(http.StatusNotFound, errors.New("no user "+id))
+	})
+	route.Get("/users/:id", func(req *Request, res *Response) error {
+		handled = true
+		return nil
+	})
+
+	rr := httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.False(t, handled)
+}
+
+func TestParamHandlerRunsOnce(t *testing.T) {
+	var calls int
+	route := NewRouter()
+	route.Param("id", func(req *Request, res *Response, next func(), id string) error {
+		calls++
+		next()
+		return nil
+	})
+	route.Get("/users/:id", func(req *Request, res *Response) error {
+		// the parameter handlers have already run for this value
+		return route.paramMiddleware(req, res, func() {})
+	})
+
+	route.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,190 @@
+package expressgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/mikaeloduh/expressgo/e"
+)
+
+// Problem Details media types, see RFC 9457
+const (
+	ProblemJSONContentType = "application/problem+json"
+	ProblemXMLContentType  = "application/problem+xml"
+)
+
+// problemXMLNamespace is the namespace of the XML problem format
+const problemXMLNamespace = "urn:ietf:rfc:7807"
+
+// Problem is a Problem Details object as defined by RFC 9457. Extension
+// members are rendered alongside the standard members.
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]any
+}
+
+// NewProblem returns the problem details of err. The fields of an e.Error found
+// in err's chain are used, with the title defaulting to the status text. Other
+// errors are reported as an internal server error without detail, so that their
+// messages are not disclosed to clients.
+func NewProblem(err error) Problem {
+	var er *e.Error
+	if !errors.As(err, &er) {
+		return Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(http.StatusInternalServerError),
+			Status: http.StatusInternalServerError,
+		}
+	}
+
+	p := Problem{
+		Type:       er.Type,
+		Title:      er.Title,
+		Status:     er.Code,
+		Detail:     er.Detail,
+		Instance:   er.Instance,
+		Extensions: er.Extensions,
+	}
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	if p.Detail == "" && er.Err != nil {
+		p.Detail = er.Err.Error()
+	}
+	return p
+}
+
+// members returns the members of the problem object. Extensions cannot replace standard members.
+func (p Problem) members() map[string]any {
+	m := make(map[string]any, len(p.Extensions)+5)
+	for key, value := range p.Extensions {
+		m[key] = value
+	}
+	m["type"] = p.Type
+	m["title"] = p.Title
+	m["status"] = p.Status
+	if p.Detail != "" {
+		m["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		m["instance"] = p.Instance
+	}
+	return m
+}
+
+func (p Problem) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.members())
+}
+
+func (p Problem) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	start = xml.StartElement{Name: xml.Name{Space: problemXMLNamespace, Local: "problem"}}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	elements := []struct {
+		name  string
+		value any
+	}{
+		{"type", p.Type},
+		{"title", p.Title},
+		{"status", strconv.Itoa(p.Status)},
+		{"detail", p.Detail},
+		{"instance", p.Instance},
+	}
+	keys := make([]string, 0, len(p.Extensions))
+	for key := range p.Extensions {
+		switch key {
+		case "type", "title", "status", "detail", "instance":
+		default:
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		elements = append(elements, struct {
+			name  string
+			value any
+		}{key, p.Extensions[key]})
+	}
+
+	for _, el := range elements {
+		if el.value == "" {
+			continue
+		}
+		if err := enc.EncodeElement(el.value, xml.StartElement{Name: xml.Name{Local: el.name}}); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// ProblemDetailsErrorHandler renders every error as Problem Details, see
+// NewProblem, in the JSON format or in the XML format if the request prefers it.
+// The instance defaults to the path of the request. Nothing is written if the
+// response is already committed. Register it last so that it
+// is consulted first:
+//
+//	router.RegisterErrorHandler(expressgo.ProblemDetailsErrorHandler)
+//
+// Handlers return e.Error values with problem details attached to control the response:
+//
+//	return e.ErrorTypeNotFound.
+//		WithType("https://example.com/probs/no-such-user").
+//		WithDetail("User 42 does not exist").
+//		WithExtension("userId", 42)
+func ProblemDetailsErrorHandler(err error, req *Request, res *Response, _ func(error)) {
+	if res.Committed() {
+		return
+	}
+
+	p := NewProblem(err)
+	if p.Instance == "" {
+		p.Instance = req.OriginalURL().Path
+	}
+
+	contentType := ProblemJSONContentType
+	if negotiate(req.Header.Get("Accept"), []string{ProblemJSONContentType, ProblemXMLContentType}) == ProblemXMLContentType {
+		contentType = ProblemXMLContentType
+	}
+
+	body, encodeErr := encodeProblem(p, contentType)
+	if encodeErr != nil {
+		// extension members that cannot be encoded are dropped rather than failing the error response
+		p.Extensions = nil
+		body, _ = encodeProblem(p, contentType)
+	}
+
+	res.Header().Set("Content-Type", contentType)
+	res.WriteHeader(p.Status)
+	_, _ = res.Write(body)
+}
+
+// encodeProblem encodes the problem in the format of the content type
+func encodeProblem(p Problem, contentType string) ([]byte, error) {
+	var body bytes.Buffer
+	if contentType == ProblemXMLContentType {
+		body.WriteString(xml.Header)
+		if err := xml.NewEncoder(&body).Encode(p); err != nil {
+			return nil, err
+		}
+		return body.Bytes(), nil
+	}
+
+	if err := json.NewEncoder(&body).Encode(p); err != nil {
+		return nil, err
+	}
+	return body.Bytes(), nil
+}
@@ -0,0 +1,99 @@
+package expressgo
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mikaeloduh/expressgo/e"
+)
+
+func TestProblemDetailsErrorHandler(t *testing.T) {
+	errNoUser := e.ErrorTypeNotFound.
+		WithType("https://example.com/probs/no-such-user").
+		WithDetail("User 42 does not exist").
+		WithExtension("userId", 42)
+
+	route := NewRouter()
+	route.RegisterErrorHandler(ProblemDetailsErrorHandler)
+	route.Get("/users/:id", func(*Request, *Response) error { return errNoUser })
+	route.Get("/wrapped", func(*Request, *Response) error {
+		return fmt.Errorf("loading order: %w", e.NewError(http.StatusConflict, errors.New("order is locked")))
+	})
+	route.Get("/internal", func(*Request, *Response) error { return errors.New("database password is wrong") })
+
+	tests := []struct {
+		path                string
+		accept              string
+		expectedCode        int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			"/users/42", "", http.StatusNotFound, ProblemJSONContentType,
+			`{"detail":"User 42 does not exist","instance":"/users/42","status":404,"title":"Not Found","type":"https://example.com/probs/no-such-user","userId":42}`,
+		},
+		{
+			"/users/42", "application/json", http.StatusNotFound, ProblemJSONContentType,
+			`{"detail":"User 42 does not exist","instance":"/users/42","status":404,"title":"Not Found","type":"https://example.com/probs/no-such-user","userId":42}`,
+		},
+		{
+			"/wrapped", "", http.StatusConflict, ProblemJSONContentType,
+			`{"detail":"order is locked","instance":"/wrapped","status":409,"title":"Conflict","type":"about:blank"}`,
+		},
+		{
+			"/internal", "", http.StatusInternalServerError, ProblemJSONContentType,
+			`{"instance":"/internal","status":500,"title":"Internal Server Error","type":"about:blank"}`,
+		},
+		{
+			"/missing", "", http.StatusNotFound, ProblemJSONContentType,
+			`{"instance":"/missing","status":404,"title":"Not Found","type":"about:blank"}`,
+		},
+		{
+			"/users/42", "application/xml", http.StatusNotFound, ProblemXMLContentType,
+			`<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<problem xmlns="urn:ietf:rfc:7807"><type>https://example.com/probs/no-such-user</type><title>Not Found</title>` +
+				`<status>404</status><detail>User 42 does not exist</detail><instance>/users/42</instance><userId>42</userId></problem>`,
+		},
+	}
+
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.accept != "" {
+			req.Header.Set("Accept", tc.accept)
+		}
+		rr := httptest.NewRecorder()
+		route.ServeHTTP(rr, req)
+
+		assert.Equal(t, tc.expectedCode, rr.Code, "Unexpected status for %s", tc.path)
+		assert.Equal(t, tc.expectedContentType, rr.Header().Get("Content-Type"), "Unexpected Content-Type for %s", tc.path)
+		if tc.expectedContentType == ProblemJSONContentType {
+			assert.JSONEq(t, tc.expectedBody, rr.Body.String(), "Unexpected body for %s", tc.path)
+		} else {
+			assert.Equal(t, tc.expectedBody, rr.Body.String(), "Unexpected body for %s", tc.path)
+		}
+	}
+}
+
+func TestErrorProblemFields(t *testing.T) {
+	err := e.ErrorTypeNotFound.WithDetail("gone").WithExtension("a", 1)
+	derived := err.WithExtension("b", 2)
+
+	assert.True(t, errors.Is(err, e.ErrorTypeNotFound))
+	assert.True(t, errors.Is(derived, e.ErrorTypeNotFound))
+	assert.True(t, errors.Is(derived, err))
+	assert.False(t, errors.Is(err, e.ErrorTypeBadRequest))
+	assert.False(t, errors.Is(e.ErrorTypeNotFound, err))
+
+	// the sentinel and the error derived from are left untouched
+	assert.Empty(t, e.ErrorTypeNotFound.Detail)
+	assert.Equal(t, map[string]any{"a": 1}, err.Extensions)
+	assert.Equal(t, map[string]any{"a": 1, "b": 2}, derived.Extensions)
+
+	cause := errors.New("cause")
+	assert.True(t, errors.Is(e.NewError(http.StatusBadRequest, cause), cause))
+}
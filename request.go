@@ -3,15 +3,77 @@ package expressgo
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 )
 
 type Request struct {
 	*http.Request
 	decoder Decoder
+	params  []pathParam
+	values  map[string]any
+
+	// router is the router handling the request, whose error templates apply
+	router *Router
+
+	// handledParams records the parameter values the param handlers already ran for
+	handledParams map[string]string
+
+	// routing is the state of the route lookup, see ErrNextRoute
+	routing routing
 }
 
 func NewRequest(r *http.Request) *Request {
-	return &Request{r, nil}
+	return &Request{Request: r}
+}
+
+// Param returns the value of the named path parameter, or "" if the route has no such parameter
+func (r *Request) Param(name string) string {
+	for _, p := range r.params {
+		if p.key == name {
+			return p.value
+		}
+	}
+	return ""
+}
+
+// Params returns a copy of all path parameters captured by the matched route
+func (r *Request) Params() map[string]string {
+	params := make(map[string]string, len(r.params))
+	for _, p := range r.params {
+		params[p.key] = p.value
+	}
+	return params
+}
+
+// Set stores a value on the request, for example a resource loaded by a
+// middleware or param handler for the handlers running after it
+func (r *Request) Set(key string, value any) {
+	if r.values == nil {
+		r.values = make(map[string]any)
+	}
+	r.values[key] = value
+}
+
+// Get returns the value stored on the request under key by Set
+func (r *Request) Get(key string) (any, bool) {
+	value, ok := r.values[key]
+	return value, ok
+}
+
+// OriginalURL returns the URL the request was received with, before any Mount stripped its prefix
+func (r *Request) OriginalURL() *url.URL {
+	if info, ok := r.Context().Value(mountContextKey{}).(*mountInfo); ok {
+		return info.originalURL
+	}
+	return r.URL
+}
+
+// BaseURL returns the path the handling router was mounted at, or "" if it was not mounted
+func (r *Request) BaseURL() string {
+	if info, ok := r.Context().Value(mountContextKey{}).(*mountInfo); ok {
+		return info.basePath
+	}
+	return ""
 }
 
 func (r *Request) SetDecoder(dec Decoder) {
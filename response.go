@@ -1,15 +1,40 @@
 package expressgo
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"sort"
+	"strings"
 
 	"github.com/mikaeloduh/expressgo/e"
 )
 
+// ErrResponseCommitted is returned when headers are changed after they were sent
+var ErrResponseCommitted = errors.New("expressgo: response already committed")
+
+// errUnsupportedContentType is returned by the end of the encoder chain when no encoder handles the Content-Type
+var errUnsupportedContentType = errors.New("unsupported Content-Type")
+
 type Response struct {
 	http.ResponseWriter
 	encoder Encoder
+
+	// request is the request the response answers, if served by a router
+	request *Request
+
+	// mediaTypes are the media types of the encoders registered with UseEncoder, in order
+	mediaTypes []string
+
+	status       int
+	committed    bool
+	bytesWritten int64
+
+	// discard drops the body written once the response failed after being committed
+	discard bool
 }
 
 // NewResponse creates a new Response
@@ -18,7 +43,7 @@ func NewResponse(w http.ResponseWriter) *Response {
 		ResponseWriter: w,
 		encoder: func(rw http.ResponseWriter, obj any) error {
 			// fallback encoder
-			return e.NewError(http.StatusInternalServerError, fmt.Errorf("unsupported Content-Type: %s", rw.Header().Get("Content-Type")))
+			return e.NewError(http.StatusInternalServerError, fmt.Errorf("%w: %s", errUnsupportedContentType, rw.Header().Get("Content-Type")))
 		},
 	}
 }
@@ -27,6 +52,300 @@ func (rs *Response) UseEncoderDecorator(enc EncoderDecorator) {
 	rs.encoder = enc(rs.encoder)
 }
 
+// UseEncoder registers enc for the media type, as an encoder decorator that
+// encodes responses whose Content-Type has the media type. Registered media types
+// take part in content negotiation, see ContentNegotiation.
+func (rs *Response) UseEncoder(mediaType string, enc Encoder) {
+	mediaType = strings.ToLower(mediaType)
+	rs.mediaTypes = append(rs.mediaTypes, mediaType)
+	rs.UseEncoderDecorator(func(next Encoder) Encoder {
+		return func(w http.ResponseWriter, obj any) error {
+			if mt, ok := parseMediaRange(w.Header().Get("Content-Type")); ok && mt.typ+"/"+mt.subtype == mediaType {
+				return enc(w, obj)
+			}
+			return next(w, obj)
+		}
+	})
+}
+
+// negotiate sets the Content-Type to the registered media type the request accepts best and adds
+// Accept to the Vary header. It reports false, leaving the Content-Type alone, if none is acceptable.
+func (rs *Response) negotiate(req *Request) bool {
+	addVary(rs.Header(), "Accept")
+
+	mediaType := negotiate(req.Header.Get("Accept"), rs.mediaTypes)
+	if mediaType == "" {
+		return false
+	}
+	rs.Header().Set("Content-Type", mediaType)
+	return true
+}
+
+// Format calls the callback of the formats whose media type the Accept header of
+// the request prefers, after setting the Content-Type to it and adding Accept to
+// the Vary header. Media types equally acceptable are preferred in lexical order.
+// If none is acceptable, it calls the "default" callback if given, and otherwise
+// returns e.ErrorTypeNotAcceptable for the error handlers to answer 406.
+//
+//	return res.Format(map[string]func() error{
+//		"text/html":        func() error { return res.HTML(page) },
+//		"application/json": func() error { return res.JSON(user) },
+//	})
+func (rs *Response) Format(formats map[string]func() error) error {
+	addVary(rs.Header(), "Accept")
+
+	offers := make([]string, 0, len(formats))
+	for mediaType := range formats {
+		if mediaType != "default" {
+			offers = append(offers, mediaType)
+		}
+	}
+	sort.Strings(offers)
+
+	var accept string
+	if rs.request != nil {
+		accept = rs.request.Header.Get("Accept")
+	}
+	if mediaType := negotiate(accept, offers); mediaType != "" {
+		rs.Header().Set("Content-Type", mediaType)
+		return formats[mediaType]()
+	}
+	if fn, ok := formats["default"]; ok {
+		return fn()
+	}
+	return e.ErrorTypeNotAcceptable
+}
+
+// addVary adds the header name to the Vary header unless it is listed already
+func addVary(header http.Header, name string) {
+	for _, value := range header.Values("Vary") {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v == "*" || strings.EqualFold(v, name) {
+				return
+			}
+		}
+	}
+	header.Add("Vary", name)
+}
+
 func (rs *Response) Encode(obj any) error {
-	return rs.encoder(rs.ResponseWriter, obj)
+	return rs.encoder(rs, obj)
+}
+
+// WriteHeader sends the response headers with the status code. Informational
+// 1xx statuses other than 101 can be sent before the final status. Once the
+// response is committed, further calls do not change the recorded status.
+func (rs *Response) WriteHeader(code int) {
+	if !rs.committed {
+		if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+			rs.ResponseWriter.WriteHeader(code)
+			return
+		}
+		rs.status = code
+		rs.committed = true
+	}
+	// a superfluous call is passed on for the server to report it
+	rs.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes the body, committing the response with the status set by Status, or 200
+func (rs *Response) Write(b []byte) (int, error) {
+	if !rs.committed {
+		rs.WriteHeader(rs.StatusCode())
+	}
+	if rs.discard {
+		return len(b), nil
+	}
+	n, err := rs.ResponseWriter.Write(b)
+	rs.bytesWritten += int64(n)
+	return n, err
+}
+
+// ReadFrom writes the body read from r, like Write, using the io.ReaderFrom of
+// the underlying writer when it has one, such as to send files with sendfile
+func (rs *Response) ReadFrom(r io.Reader) (int64, error) {
+	if !rs.committed {
+		rs.WriteHeader(rs.StatusCode())
+	}
+	if rf, ok := rs.ResponseWriter.(io.ReaderFrom); ok && !rs.discard {
+		n, err := rf.ReadFrom(r)
+		rs.bytesWritten += n
+		return n, err
+	}
+	return io.Copy(writerOnly{rs}, r)
+}
+
+// writerOnly hides the ReadFrom method of a writer from io.Copy
+type writerOnly struct {
+	io.Writer
+}
+
+// Flush sends the body written so far to the client, committing the response,
+// if the underlying writer supports flushing
+func (rs *Response) Flush() {
+	if !rs.committed {
+		rs.WriteHeader(rs.StatusCode())
+	}
+	_ = http.NewResponseController(rs.ResponseWriter).Flush()
+}
+
+// Hijack lets the caller take over the connection, see http.Hijacker. It
+// returns an error wrapping http.ErrNotSupported if the underlying writer
+// does not support it.
+func (rs *Response) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(rs.ResponseWriter).Hijack()
+	if err == nil {
+		rs.committed = true
+	}
+	return conn, rw, err
+}
+
+// Status sets the status code sent with the body written next, for chaining:
+//
+//	return res.Status(http.StatusCreated).JSON(user)
+//
+// It has no effect once the response is committed.
+func (rs *Response) Status(code int) *Response {
+	if !rs.committed {
+		rs.status = code
+	}
+	return rs
+}
+
+// StatusCode returns the status code sent or set with Status, or else 200
+func (rs *Response) StatusCode() int {
+	if rs.status == 0 {
+		return http.StatusOK
+	}
+	return rs.status
+}
+
+// Committed reports whether the headers were sent, after which they can no longer be changed
+func (rs *Response) Committed() bool {
+	return rs.committed
+}
+
+// Written reports whether any body was written
+func (rs *Response) Written() bool {
+	return rs.bytesWritten > 0
+}
+
+// BytesWritten returns the number of body bytes written
+func (rs *Response) BytesWritten() int64 {
+	return rs.bytesWritten
+}
+
+// SetHeader sets the response header key to value. Unlike changes made through
+// Header, which are silently lost once the response is committed, it returns
+// ErrResponseCommitted then.
+func (rs *Response) SetHeader(key string, value string) error {
+	if rs.committed {
+		return fmt.Errorf("%w: cannot set header %q", ErrResponseCommitted, key)
+	}
+	rs.Header().Set(key, value)
+	return nil
+}
+
+// AddHeader adds value to the response header key, see SetHeader
+func (rs *Response) AddHeader(key string, value string) error {
+	if rs.committed {
+		return fmt.Errorf("%w: cannot add header %q", ErrResponseCommitted, key)
+	}
+	rs.Header().Add(key, value)
+	return nil
+}
+
+// Unwrap returns the underlying ResponseWriter, for use with http.ResponseController
+func (rs *Response) Unwrap() http.ResponseWriter {
+	return rs.ResponseWriter
+}
+
+// JSON sets the Content-Type to application/json and encodes v through the
+// encoder chain, which falls back to JSONEncoder if no encoder handles JSON
+func (rs *Response) JSON(v any) error {
+	return rs.send("application/json", v, JSONEncoder)
+}
+
+// XML sets the Content-Type to application/xml and encodes v through the
+// encoder chain, which falls back to XMLEncoder if no encoder handles XML
+func (rs *Response) XML(v any) error {
+	return rs.send("application/xml", v, XMLEncoder)
+}
+
+// Text sets the Content-Type to plain text and writes s, unless an encoder of the chain handles plain text
+func (rs *Response) Text(s string) error {
+	return rs.send("text/plain; charset=utf-8", s, stringEncoder)
+}
+
+// HTML sets the Content-Type to HTML and writes s, unless an encoder of the chain handles HTML
+func (rs *Response) HTML(s string) error {
+	return rs.send("text/html; charset=utf-8", s, stringEncoder)
+}
+
+// Send writes v in a format chosen by its type. Strings and byte slices are
+// written as they are, with the Content-Type sniffed from their content if it is
+// not set. Other values are encoded through the encoder chain in the format of
+// the Content-Type set, for instance by JSONBodyEncoder, or else as JSON. A nil
+// v sends the status without a body.
+func (rs *Response) Send(v any) error {
+	switch v := v.(type) {
+	case nil:
+		rs.WriteHeader(rs.StatusCode())
+		return nil
+	case string:
+		return rs.Send([]byte(v))
+	case []byte:
+		if rs.Header().Get("Content-Type") == "" {
+			rs.Header().Set("Content-Type", http.DetectContentType(v))
+		}
+		_, err := rs.Write(v)
+		return err
+	}
+
+	contentType := rs.Header().Get("Content-Type")
+	if contentType == "" {
+		return rs.JSON(v)
+	}
+	return rs.send(contentType, v, fallbackEncoder(contentType))
+}
+
+// fallbackEncoder returns the encoder of the default codecs for the Content-Type, or nil if there is none
+func fallbackEncoder(contentType string) Encoder {
+	codec, _ := DefaultCodecs.Lookup(contentType)
+	return codec.Encoder
+}
+
+// Redirect redirects the request to url with the redirection status code
+func (rs *Response) Redirect(code int, url string) error {
+	if code < 300 || code > 399 {
+		return fmt.Errorf("expressgo: invalid redirect status code %d", code)
+	}
+	if err := rs.SetHeader("Location", url); err != nil {
+		return err
+	}
+	rs.WriteHeader(code)
+	return nil
+}
+
+// NoContent sends the status 204 No Content without a body
+func (rs *Response) NoContent() error {
+	rs.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// send sets the Content-Type and encodes v through the encoder chain, using
+// fallback if no encoder of the chain handles the Content-Type
+func (rs *Response) send(contentType string, v any, fallback Encoder) error {
+	rs.Header().Set("Content-Type", contentType)
+	err := rs.Encode(v)
+	if errors.Is(err, errUnsupportedContentType) && fallback != nil {
+		return fallback(rs, v)
+	}
+	return err
+}
+
+// stringEncoder writes a string as it is
+func stringEncoder(w http.ResponseWriter, v any) error {
+	_, err := io.WriteString(w, v.(string))
+	return err
 }
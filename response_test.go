@@ -0,0 +1,236 @@
+package expressgo
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseState(t *testing.T) {
+	rr := httptest.NewRecorder()
+	res := NewResponse(rr)
+
+	assert.False(t, res.Committed())
+	assert.False(t, res.Written())
+	assert.Equal(t, http.StatusOK, res.StatusCode())
+	assert.NoError(t, res.SetHeader("Content-Type", "text/plain"))
+
+	res.WriteHeader(http.StatusCreated)
+	assert.True(t, res.Committed())
+	assert.False(t, res.Written())
+
+	_, _ = res.Write([]byte("hello"))
+	_, _ = res.Write([]byte(" world"))
+	assert.True(t, res.Written())
+	assert.Equal(t, int64(11), res.BytesWritten())
+	assert.Equal(t, http.StatusCreated, res.StatusCode())
+
+	res.WriteHeader(http.StatusNotFound)
+	assert.Equal(t, http.StatusCreated, res.StatusCode())
+
+	err := res.SetHeader("X-Late", "1")
+	assert.True(t, errors.Is(err, ErrResponseCommitted))
+	assert.True(t, errors.Is(res.AddHeader("X-Late", "1"), ErrResponseCommitted))
+	assert.Equal(t, rr, res.Unwrap())
+}
+
+func TestResponseImplicitStatus(t *testing.T) {
+	res := NewResponse(httptest.NewRecorder())
+	_, _ = res.Write([]byte("ok"))
+
+	assert.True(t, res.Committed())
+	assert.Equal(t, http.StatusOK, res.StatusCode())
+}
+
+func TestHandleErrorAfterCommit(t *testing.T) {
+	var handled error
+	route := NewRouter()
+	route.RegisterErrorHandler(func(err error, req *Request, res *Response, next func(error)) {
+		handled = err
+		next(err)
+	})
+	route.Get("/stream", func(_ *Request, res *Response) error {
+		res.WriteHeader(http.StatusAccepted)
+		_, _ = res.Write([]byte("partial"))
+		return errors.New("stream broken")
+	})
+
+	rr := httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/stream", nil))
+
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	assert.Equal(t, "partial", rr.Body.String())
+	assert.Empty(t, rr.Header().Get("Content-Type"))
+	assert.EqualError(t, handled, "stream broken")
+}
+
+func TestWrappedHandlerResponseState(t *testing.T) {
+	var committed bool
+	var written int64
+	route := NewRouter()
+	route.UseAround(func(req *Request, res *Response, next func() error) error {
+		_ = next()
+		committed, written = res.Committed(), res.BytesWritten()
+		return errors.New("after commit")
+	})
+	route.Get("/wrapped", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("hi"))
+	}))
+
+	rr := httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/wrapped", nil))
+
+	assert.True(t, committed)
+	assert.Equal(t, int64(2), written)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, "hi", rr.Body.String())
+}
+
+func TestWrappedHandlerStreaming(t *testing.T) {
+	var flusher, hijacker bool
+	route := NewRouter()
+	route.Get("/events", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, hijacker = w.(http.Hijacker)
+		var f http.Flusher
+		if f, flusher = w.(http.Flusher); flusher {
+			_, _ = w.Write([]byte("data: 1\n\n"))
+			f.Flush()
+		}
+		_, _ = io.Copy(w, strings.NewReader("data: 2\n\n"))
+	}))
+
+	rr := httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	assert.True(t, flusher)
+	assert.True(t, hijacker)
+	assert.True(t, rr.Flushed)
+	assert.Equal(t, "data: 1\n\ndata: 2\n\n", rr.Body.String())
+
+	_, _, err := NewResponse(rr).Hijack()
+	assert.ErrorIs(t, err, http.ErrNotSupported)
+}
+
+func TestResponseHelpers(t *testing.T) {
+	type user struct {
+		Name string `json:"name" xml:"name"`
+	}
+
+	tests := []struct {
+		name                string
+		send                func(res *Response) error
+		expectedCode        int
+		expectedContentType string
+		expectedBody        string
+		expectedLocation    string
+	}{
+		{"json", func(res *Response) error { return res.Status(http.StatusCreated).JSON(user{"ann"}) },
+			http.StatusCreated, "application/json", "{\"name\":\"ann\"}\n", ""},
+		{"xml", func(res *Response) error { return res.XML(user{"ann"}) },
+			http.StatusOK, "application/xml", "<user><name>ann</name></user>", ""},
+		{"text", func(res *Response) error { return res.Status(http.StatusAccepted).Text("queued") },
+			http.StatusAccepted, "text/plain; charset=utf-8", "queued", ""},
+		{"html", func(res *Response) error { return res.HTML("<p>hi</p>") },
+			http.StatusOK, "text/html; charset=utf-8", "<p>hi</p>", ""},
+		{"send html", func(res *Response) error { return res.Send("<html><body>hi</body></html>") },
+			http.StatusOK, "text/html; charset=utf-8", "<html><body>hi</body></html>", ""},
+		{"send text", func(res *Response) error { return res.Send("hi") },
+			http.StatusOK, "text/plain; charset=utf-8", "hi", ""},
+		{"send bytes", func(res *Response) error { return res.Send([]byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}) },
+			http.StatusOK, "image/png", "\x89PNG\r\n\x1a\n", ""},
+		{"send struct", func(res *Response) error { return res.Send(user{"ann"}) },
+			http.StatusOK, "application/json", "{\"name\":\"ann\"}\n", ""},
+		{"send negotiated", func(res *Response) error {
+			res.Header().Set("Content-Type", "application/vnd.api+xml")
+			return res.Send(user{"ann"})
+		}, http.StatusOK, "application/vnd.api+xml", "<user><name>ann</name></user>", ""},
+		{"send nil", func(res *Response) error { return res.Status(http.StatusGone).Send(nil) },
+			http.StatusGone, "", "", ""},
+		{"redirect", func(res *Response) error { return res.Redirect(http.StatusSeeOther, "/login") },
+			http.StatusSeeOther, "", "", "/login"},
+		{"no content", func(res *Response) error { return res.NoContent() },
+			http.StatusNoContent, "", "", ""},
+	}
+
+	for _, tc := range tests {
+		rr := httptest.NewRecorder()
+		assert.NoError(t, tc.send(NewResponse(rr)), "Unexpected error for %s", tc.name)
+		assert.Equal(t, tc.expectedCode, rr.Code, "Unexpected status for %s", tc.name)
+		assert.Equal(t, tc.expectedContentType, rr.Header().Get("Content-Type"), "Unexpected Content-Type for %s", tc.name)
+		assert.Equal(t, tc.expectedBody, rr.Body.String(), "Unexpected body for %s", tc.name)
+		assert.Equal(t, tc.expectedLocation, rr.Header().Get("Location"), "Unexpected Location for %s", tc.name)
+	}
+
+	assert.Error(t, NewResponse(httptest.NewRecorder()).Redirect(http.StatusOK, "/"))
+}
+
+func TestResponseHelpersUseEncoderChain(t *testing.T) {
+	rr := httptest.NewRecorder()
+	res := NewResponse(rr)
+	res.UseEncoderDecorator(JSONEncoderDecorator)
+	res.UseEncoderDecorator(func(next Encoder) Encoder {
+		return func(w http.ResponseWriter, obj any) error {
+			if w.Header().Get("Content-Type") == "application/json" {
+				return JSONEncoder(w, map[string]any{"data": obj})
+			}
+			return next(w, obj)
+		}
+	})
+
+	assert.NoError(t, res.JSON([]int{1, 2}))
+	assert.Equal(t, "{\"data\":[1,2]}\n", rr.Body.String())
+}
+
+func TestResponseFormat(t *testing.T) {
+	formats := func(res *Response, withDefault bool) map[string]func() error {
+		f := map[string]func() error{
+			"text/html":        func() error { return res.HTML("<p>ann</p>") },
+			"application/json": func() error { return res.JSON(map[string]string{"name": "ann"}) },
+			"text/csv":         func() error { _, err := res.Write([]byte("name\nann\n")); return err },
+		}
+		if withDefault {
+			f["default"] = func() error { return res.Text("ann") }
+		}
+		return f
+	}
+
+	route := NewRouter()
+	route.Get("/users/ann", func(req *Request, res *Response) error {
+		return res.Format(formats(res, req.URL.Query().Has("default")))
+	})
+
+	tests := []struct {
+		name                string
+		target              string
+		accept              string
+		expectedCode        int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{"html", "/users/ann", "text/html,application/xhtml+xml,*/*;q=0.8", http.StatusOK, "text/html; charset=utf-8", "<p>ann</p>"},
+		{"json", "/users/ann", "application/json", http.StatusOK, "application/json", "{\"name\":\"ann\"}\n"},
+		{"csv", "/users/ann", "text/csv, application/json;q=0.5", http.StatusOK, "text/csv", "name\nann\n"},
+		{"no accept", "/users/ann", "", http.StatusOK, "application/json", "{\"name\":\"ann\"}\n"},
+		{"not acceptable", "/users/ann", "image/png", http.StatusNotAcceptable, "text/plain; charset=utf-8", "Not Acceptable"},
+		{"default", "/users/ann?default", "image/png", http.StatusOK, "text/plain; charset=utf-8", "ann"},
+	}
+
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+		req.Header.Set("Accept", tc.accept)
+		rr := httptest.NewRecorder()
+
+		route.ServeHTTP(rr, req)
+
+		assert.Equal(t, tc.expectedCode, rr.Code, "Unexpected status for %s", tc.name)
+		assert.Equal(t, tc.expectedContentType, rr.Header().Get("Content-Type"), "Unexpected Content-Type for %s", tc.name)
+		assert.Equal(t, tc.expectedBody, rr.Body.String(), "Unexpected body for %s", tc.name)
+		assert.Equal(t, "Accept", rr.Header().Get("Vary"), "Unexpected Vary for %s", tc.name)
+	}
+}
@@ -0,0 +1,437 @@
+package expressgo
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+)
+
+// routeTable holds the routes of a router and of all its groups.
+//
+// Registrations copy the parts of the routes they change and publish them as a
+// new snapshot, so requests keep matching against a consistent set of routes
+// without locking while routes are added and removed.
+type routeTable struct {
+	options routerOptions
+	mu      sync.Mutex // serializes registrations
+	current atomic.Pointer[routeSnapshot]
+}
+
+// routeSnapshot is a set of routes. Once published it is never modified.
+type routeSnapshot struct {
+	tree   *node
+	names  map[string]string
+	mounts []mount
+	hosts  []*hostRouter
+}
+
+// mount records a Router mounted under a path pattern
+type mount struct {
+	owner   *Router
+	pattern string
+	router  *Router
+}
+
+func newRouteTable() *routeTable {
+	t := &routeTable{}
+	t.current.Store(&routeSnapshot{
+		tree:  &node{},
+		names: make(map[string]string),
+	})
+	return t
+}
+
+// routes returns the current snapshot of the routes
+func (t *routeTable) routes() *routeSnapshot {
+	return t.current.Load()
+}
+
+// update calls fn with a copy of the current snapshot and publishes it once fn returns.
+// Only the root of the tree is copied: fn must copy any other node, map or slice it changes.
+func (t *routeTable) update(fn func(s *routeSnapshot)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := *t.current.Load()
+	s.tree = s.tree.clone()
+	fn(&s)
+	t.current.Store(&s)
+}
+
+// Route is a route pattern of a router. It is returned by Router.Route to
+// register several methods on one path, and by Handle and the method shortcuts
+// to further configure the route just registered:
+//
+//	router.Route("/books/:id").
+//		Use(loadBook).
+//		Get(showBook).
+//		Put(replaceBook).
+//		Delete(deleteBook)
+type Route struct {
+	router        *Router
+	pattern       string
+	middlewares   []link
+	errorHandlers []ErrorHandlerFunc
+	changes       uint64
+}
+
+// Route returns a Route for path relative to the router's prefix, see Handle for the path syntax
+func (rt *Router) Route(path string) *Route {
+	return &Route{router: rt, pattern: rt.fullPath(path)}
+}
+
+// Use adds middleware running for every method registered through the route,
+// after the router middleware and before the middleware given for the method
+func (r *Route) Use(middleware ...Middleware) *Route {
+	r.middlewares = append(r.middlewares, links(middleware)...)
+	r.changes++
+	return r
+}
+
+// UseAround adds middleware whose next returns the downstream error, see Router.UseAround and Use
+func (r *Route) UseAround(middleware ...AroundMiddleware) *Route {
+	for _, mw := range middleware {
+		r.middlewares = append(r.middlewares, link{around: mw})
+	}
+	r.changes++
+	return r
+}
+
+// RegisterErrorHandler registers an error handler for the errors of the route,
+// consulted before the error handlers of its router, see Router.RegisterErrorHandler
+func (r *Route) RegisterErrorHandler(handlerFunc ErrorHandlerFunc) *Route {
+	r.errorHandlers = append([]ErrorHandlerFunc{handlerFunc}, r.errorHandlers...)
+	return r
+}
+
+// Handle registers handlers for the method of the route, see Router.Handle
+func (r *Route) Handle(method string, handlers ...any) *Route {
+	handler, middlewares := splitHandlers(handlers)
+	r.router.handle(r.pattern, method, &endpoint{router: r.router, route: r, handler: handler, middlewares: middlewares})
+	return r
+}
+
+// Get registers handlers for GET requests on the route, see Router.Get
+func (r *Route) Get(handlers ...any) *Route {
+	return r.Handle(http.MethodGet, handlers...)
+}
+
+// Head registers handlers for HEAD requests on the route, see Router.Get
+func (r *Route) Head(handlers ...any) *Route {
+	return r.Handle(http.MethodHead, handlers...)
+}
+
+// Post registers handlers for POST requests on the route, see Router.Get
+func (r *Route) Post(handlers ...any) *Route {
+	return r.Handle(http.MethodPost, handlers...)
+}
+
+// Put registers handlers for PUT requests on the route, see Router.Get
+func (r *Route) Put(handlers ...any) *Route {
+	return r.Handle(http.MethodPut, handlers...)
+}
+
+// Patch registers handlers for PATCH requests on the route, see Router.Get
+func (r *Route) Patch(handlers ...any) *Route {
+	return r.Handle(http.MethodPatch, handlers...)
+}
+
+// Delete registers handlers for DELETE requests on the route, see Router.Get
+func (r *Route) Delete(handlers ...any) *Route {
+	return r.Handle(http.MethodDelete, handlers...)
+}
+
+// Options registers handlers for OPTIONS requests on the route, see Router.Get
+func (r *Route) Options(handlers ...any) *Route {
+	return r.Handle(http.MethodOptions, handlers...)
+}
+
+// All registers handlers for every method of the route without handlers of its own, see Router.Get
+func (r *Route) All(handlers ...any) *Route {
+	return r.Handle(methodAll, handlers...)
+}
+
+// Pattern returns the full path pattern of the route, including the prefix of its group
+func (r *Route) Pattern() string {
+	return r.pattern
+}
+
+// Name names the route so that its URL can be built with Router.URL.
+// It panics if the name is already used by a route with another pattern.
+func (r *Route) Name(name string) *Route {
+	r.router.table.update(func(s *routeSnapshot) {
+		if pattern, ok := s.names[name]; ok && pattern != r.pattern {
+			panic(fmt.Sprintf("expressgo: route name %q for %q is already used by %q", name, r.pattern, pattern))
+		}
+		s.names = maps.Clone(s.names)
+		s.names[name] = r.pattern
+	})
+	return r
+}
+
+// URL builds the path of the named route. Pairs are alternating parameter names
+// and values: values of parameters in the route pattern are substituted into the
+// path, and the remaining pairs are encoded as the query string.
+//
+//	router.Get("/users/:id", userHandler).Name("user")
+//	router.URL("user", "id", "42", "tab", "posts") // "/users/42?tab=posts"
+//
+// Routes of groups and mounted routers can be built as well. Routes of host
+// routers are built as scheme-relative URLs, "//host/path", with the parameters
+// of the host pattern substituted like those of the path. URL returns an error
+// if the name is unknown or a parameter of the patterns is missing.
+func (rt *Router) URL(name string, pairs ...string) (string, error) {
+	if len(pairs)%2 != 0 {
+		return "", fmt.Errorf("expressgo: odd number of URL parameters for route %q", name)
+	}
+
+	host, patterns, ok := rt.table.namedPatterns(name)
+	if !ok {
+		return "", fmt.Errorf("expressgo: no route named %q", name)
+	}
+
+	params := make(map[string]string, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		params[pairs[i]] = pairs[i+1]
+	}
+
+	var path strings.Builder
+	if host != nil {
+		path.WriteString("//")
+		if err := host.expand(&path, params); err != nil {
+			return "", fmt.Errorf("expressgo: route %q: %w", name, err)
+		}
+	}
+	prefix := path.Len()
+	for _, pattern := range patterns {
+		if err := expandPattern(&path, pattern, params); err != nil {
+			return "", fmt.Errorf("expressgo: route %q: %w", name, err)
+		}
+	}
+
+	u := path.String()
+	if last := patterns[len(patterns)-1]; len(u) == prefix || (strings.HasSuffix(last, "/") && last != "/") {
+		u += "/"
+	}
+	if len(params) > 0 {
+		query := url.Values{}
+		for i := 0; i < len(pairs); i += 2 {
+			if _, ok := params[pairs[i]]; ok {
+				query.Add(pairs[i], pairs[i+1])
+			}
+		}
+		u += "?" + query.Encode()
+	}
+	return u, nil
+}
+
+// namedPatterns returns the patterns leading to the named route, outermost mount
+// first, along with the host router serving it if any
+func (t *routeTable) namedPatterns(name string) (*hostRouter, []string, bool) {
+	s := t.routes()
+	if pattern, ok := s.names[name]; ok {
+		return nil, []string{pattern}, true
+	}
+	for _, m := range s.mounts {
+		if host, patterns, ok := m.router.table.namedPatterns(name); ok {
+			return host, append([]string{m.pattern}, patterns...), true
+		}
+	}
+	for _, h := range s.hosts {
+		if host, patterns, ok := h.router.table.namedPatterns(name); ok {
+			if host == nil {
+				host = h
+			}
+			return host, patterns, true
+		}
+	}
+	return nil, nil, false
+}
+
+// expandPattern writes the pattern to b with its parameters substituted from params.
+// Substituted parameters are deleted from params.
+func expandPattern(b *strings.Builder, pattern string, params map[string]string) error {
+	for _, segment := range strings.Split(strings.Trim(pattern, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		b.WriteByte('/')
+
+		switch segment[0] {
+		case ':', '{':
+			name, c, err := parseParam(segment)
+			if err != nil {
+				return err
+			}
+			value, ok := params[name]
+			if !ok || value == "" {
+				return fmt.Errorf("missing parameter %q", name)
+			}
+			if !c.match(value) {
+				return fmt.Errorf("parameter %q does not match %q", name, c)
+			}
+			delete(params, name)
+			b.WriteString(url.PathEscape(value))
+		case '*':
+			name := segment[1:]
+			if name == "" {
+				name = "*"
+			}
+			value := strings.Trim(params[name], "/")
+			delete(params, name)
+			for i, part := range strings.Split(value, "/") {
+				if i > 0 {
+					b.WriteByte('/')
+				}
+				b.WriteString(url.PathEscape(part))
+			}
+		default:
+			b.WriteString(segment)
+		}
+	}
+	return nil
+}
+
+// RouteInfo describes a registered route
+type RouteInfo struct {
+	Host        string
+	Method      string
+	Path        string
+	Name        string
+	Middlewares []string
+}
+
+// Routes returns the routes registered on the router and its groups, including
+// the routes of mounted and host routers, sorted by host, path and method. Routes matching any
+// method are reported with the method "ALL". Middlewares lists the names of the
+// middleware functions the route runs, outermost first.
+func (rt *Router) Routes() []RouteInfo {
+	var routes []RouteInfo
+	rt.collectRoutes("", nil, &routes)
+
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Host != routes[j].Host {
+			return routes[i].Host < routes[j].Host
+		}
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// PrintRoutes writes the routes of the router to w as an aligned table
+func (rt *Router) PrintRoutes(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATH\tNAME\tMIDDLEWARE")
+	for _, r := range rt.Routes() {
+		// routes of host routers are printed with their host pattern, e.g. "{tenant}.example.com/dash"
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Method, r.Host+r.Path, r.Name, strings.Join(r.Middlewares, ", "))
+	}
+	return tw.Flush()
+}
+
+// collectRoutes appends the routes owned by the router to routes, with prefix
+// prepended to their paths and outer middleware names before their own
+func (rt *Router) collectRoutes(prefix string, outer []string, routes *[]RouteInfo) {
+	s := rt.table.routes()
+	names := make(map[string]string, len(s.names))
+	for name, pattern := range s.names {
+		if current, ok := names[pattern]; !ok || name < current {
+			names[pattern] = name
+		}
+	}
+
+	// the routes forwarding to mounted routers are listed as the routes of those routers
+	mounted := make(map[string]bool, 2*len(s.mounts))
+	for _, m := range s.mounts {
+		mounted[routePath(m.pattern)] = true
+		mounted[m.pattern+"/*"] = true
+	}
+
+	s.tree.walk(func(l *leaf) {
+		if mounted[l.pattern] {
+			return
+		}
+		for method, ep := range l.handlers {
+			if !rt.owns(ep.router) {
+				continue
+			}
+			if method == methodAll {
+				method = "ALL"
+			}
+			*routes = append(*routes, RouteInfo{
+				Method:      method,
+				Path:        joinPath(prefix, l.pattern),
+				Name:        names[l.pattern],
+				Middlewares: append(append(outer[:len(outer):len(outer)], ep.router.middlewareNames()...), funcNames(ep.routeMiddlewares())...),
+			})
+		}
+	})
+
+	for _, m := range s.mounts {
+		if !rt.owns(m.owner) {
+			continue
+		}
+		outer := append(outer[:len(outer):len(outer)], m.owner.middlewareNames()...)
+		m.router.collectRoutes(prefix+m.pattern, outer, routes)
+	}
+
+	for _, h := range s.hosts {
+		if !rt.owns(h.router.parent) {
+			continue
+		}
+		from := len(*routes)
+		h.router.collectRoutes(prefix, outer, routes)
+		for i := from; i < len(*routes); i++ {
+			(*routes)[i].Host = h.pattern
+		}
+	}
+}
+
+// joinPath prepends the path a router is mounted at to one of its route patterns
+func joinPath(prefix string, pattern string) string {
+	if prefix != "" && pattern == "/" {
+		return prefix
+	}
+	return prefix + pattern
+}
+
+// owns reports whether r is the router itself or one of its groups
+func (rt *Router) owns(r *Router) bool {
+	for ; r != nil; r = r.parent {
+		if r == rt {
+			return true
+		}
+	}
+	return false
+}
+
+// middlewareNames returns the names of the middleware of the router and all its parents, outermost first
+func (rt *Router) middlewareNames() []string {
+	var names []string
+	for r := rt; r != nil; r = r.parent {
+		names = append(funcNames(r.middlewares), names...)
+	}
+	return names
+}
+
+// funcNames returns the package qualified names of the middleware functions
+func funcNames(middlewares []link) []string {
+	names := make([]string, 0, len(middlewares))
+	for _, mw := range middlewares {
+		name := runtime.FuncForPC(reflect.ValueOf(mw.fn()).Pointer()).Name()
+		names = append(names, name[strings.LastIndex(name, "/")+1:])
+	}
+	return names
+}
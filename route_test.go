@@ -0,0 +1,187 @@
+package expressgo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouterURL(t *testing.T) {
+	admin := NewRouter()
+	admin.Get("/reports/:year", homeHandler).Name("report")
+
+	route := NewRouter()
+	route.Get("/", homeHandler).Name("home")
+	route.Get("/users/:id/posts/:postId", homeHandler).Name("post")
+	route.Get("/files/*filepath", homeHandler).Name("file")
+	route.Group("/api").Group("/v1").Get("/books/:id", homeHandler).Name("book")
+	route.Mount("/tenants/:tenant/admin", admin)
+
+	tests := []struct {
+		name        string
+		pairs       []string
+		expectedURL string
+	}{
+		{"home", nil, "/"},
+		{"home", []string{"page", "2"}, "/?page=2"},
+		{"post", []string{"id", "42", "postId", "7"}, "/users/42/posts/7"},
+		{"post", []string{"postId", "7", "id", "a b", "sort", "desc", "q", "x&y"}, "/users/a%20b/posts/7?q=x%26y&sort=desc"},
+		{"file", []string{"filepath", "css/app.css"}, "/files/css/app.css"},
+		{"book", []string{"id", "9"}, "/api/v1/books/9"},
+		{"report", []string{"tenant", "acme", "year", "2024"}, "/tenants/acme/admin/reports/2024"},
+	}
+
+	for _, tc := range tests {
+		u, err := route.URL(tc.name, tc.pairs...)
+		assert.NoError(t, err, "Unexpected error for route %s", tc.name)
+		assert.Equal(t, tc.expectedURL, u, "Unexpected URL for route %s", tc.name)
+	}
+}
+
+func TestRouterURLErrors(t *testing.T) {
+	route := NewRouter()
+	route.Get("/users/:id", homeHandler).Name("user")
+
+	_, err := route.URL("missing")
+	assert.EqualError(t, err, "expressgo: no route named \"missing\"")
+
+	_, err = route.URL("user")
+	assert.EqualError(t, err, "expressgo: route \"user\": missing parameter \"id\"")
+
+	_, err = route.URL("user", "id")
+	assert.EqualError(t, err, "expressgo: odd number of URL parameters for route \"user\"")
+}
+
+func TestRouteNameConflict(t *testing.T) {
+	route := NewRouter()
+	route.Get("/users/:id", homeHandler).Name("user")
+
+	assert.NotPanics(t, func() { route.Post("/users/:id", homeHandler).Name("user") })
+	assert.Panics(t, func() { route.Get("/accounts/:id", homeHandler).Name("user") })
+}
+
+func TestRouterRoutes(t *testing.T) {
+	admin := NewRouter()
+	admin.Use(testMiddleware)
+	admin.Get("/stats", homeHandler).Name("stats")
+
+	route := NewRouter()
+	route.Use(tagMiddleware("root"))
+	route.Get("/", homeHandler).Name("home")
+	route.Post("/users", testMiddleware, homeHandler)
+	route.All("/any", homeHandler)
+
+	api := route.Group("/api", testMiddleware)
+	api.Get("/users/:id", homeHandler).Name("user")
+	api.Mount("/admin", admin)
+
+	expected := []RouteInfo{
+		{Method: "GET", Path: "/", Name: "home", Middlewares: []string{"expressgo.tagMiddleware.func1"}},
+		{Method: "ALL", Path: "/any", Name: "", Middlewares: []string{"expressgo.tagMiddleware.func1"}},
+		{Method: "GET", Path: "/api/admin/stats", Name: "stats", Middlewares: []string{"expressgo.tagMiddleware.func1", "expressgo.testMiddleware", "expressgo.testMiddleware"}},
+		{Method: "GET", Path: "/api/users/:id", Name: "user", Middlewares: []string{"expressgo.tagMiddleware.func1", "expressgo.testMiddleware"}},
+		{Method: "POST", Path: "/users", Name: "", Middlewares: []string{"expressgo.tagMiddleware.func1", "expressgo.testMiddleware"}},
+	}
+	assert.Equal(t, expected, route.Routes())
+
+	// a group only reports its own routes
+	assert.Equal(t, []RouteInfo{expected[2], expected[3]}, api.Routes())
+}
+
+func TestRouterPrintRoutes(t *testing.T) {
+	route := NewRouter()
+	route.Get("/", homeHandler).Name("home")
+	route.Post("/users/:id", testMiddleware, homeHandler)
+	route.Get("/dash", homeHandler)
+	route.Host("{tenant}.example.com").Get("/dash", homeHandler)
+
+	var b strings.Builder
+	assert.NoError(t, route.PrintRoutes(&b))
+
+	expected := "" +
+		"METHOD  PATH                       NAME  MIDDLEWARE\n" +
+		"GET     /                          home  \n" +
+		"GET     /dash                            \n" +
+		"POST    /users/:id                       expressgo.testMiddleware\n" +
+		"GET     {tenant}.example.com/dash        \n"
+	assert.Equal(t, expected, b.String())
+}
+
+func TestRouteChaining(t *testing.T) {
+	route := NewRouter()
+	route.Use(tagMiddleware("root"))
+
+	respond := func(body string) func(*Request, *Response) error {
+		return func(req *Request, res *Response) error {
+			fmt.Fprintf(res, "%s %s", body, req.Param("id"))
+			return nil
+		}
+	}
+
+	route.Group("/api").Route("/books/:id").
+		Get(respond("show")).
+		Put(tagMiddleware("put"), respond("replace")).
+		Delete(respond("delete")).
+		Use(tagMiddleware("book"))
+
+	tests := []struct {
+		method        string
+		expectedCode  int
+		expectedBody  string
+		expectedTrace []string
+	}{
+		{http.MethodGet, http.StatusOK, "show 7", []string{"root", "book"}},
+		{http.MethodPut, http.StatusOK, "replace 7", []string{"root", "book", "put"}},
+		{http.MethodDelete, http.StatusOK, "delete 7", []string{"root", "book"}},
+		{http.MethodPost, http.StatusMethodNotAllowed, "Method \"POST\" is not allowed on path \"api/books/7\"", nil},
+	}
+
+	for _, tc := range tests {
+		rr := httptest.NewRecorder()
+		route.ServeHTTP(rr, httptest.NewRequest(tc.method, "/api/books/7", nil))
+
+		assert.Equal(t, tc.expectedCode, rr.Code, "Unexpected status code for %s", tc.method)
+		assert.Equal(t, tc.expectedBody, rr.Body.String(), "Unexpected response body for %s", tc.method)
+		assert.Equal(t, tc.expectedTrace, rr.Header().Values("X-Trace"), "Unexpected middleware for %s", tc.method)
+	}
+
+	rr := httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/books/7", nil))
+	assert.Equal(t, "DELETE, GET, HEAD, OPTIONS, PUT", rr.Header().Get("Allow"))
+}
+
+func TestRouterRemove(t *testing.T) {
+	route := NewRouter()
+	route.Get("/users/:id", homeHandler).Name("user")
+	route.Put("/users/:id", homeHandler)
+	route.All("/any", homeHandler)
+	api := route.Group("/api")
+	api.Get("/books", homeHandler)
+
+	assert.True(t, route.Remove(http.MethodGet, "/users/:id"))
+	assert.False(t, route.Remove(http.MethodGet, "/users/:id"))
+	assert.True(t, route.Remove("ALL", "/any"))
+	assert.True(t, api.Remove(http.MethodGet, "/books"))
+
+	assert.Equal(t, []RouteInfo{
+		{Method: http.MethodPut, Path: "/users/:id", Name: "user"},
+	}, route.Routes())
+
+	rr := httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+
+	rr = httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/books", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// the name is released along with the last method of its route, and the parameter can be renamed
+	assert.True(t, route.Remove(http.MethodPut, "/users/:id"))
+	_, err := route.URL("user")
+	assert.Error(t, err)
+	assert.NotPanics(t, func() { route.Get("/users/:name", homeHandler).Name("user") })
+}
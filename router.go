@@ -1,12 +1,24 @@
 package expressgo
 
 import (
+	"errors"
+	"maps"
 	"net/http"
+	"sort"
 	"strings"
 
 	"github.com/mikaeloduh/expressgo/e"
 )
 
+// ErrNextRoute is returned by a route's middleware or handler to skip the rest
+// of the route and continue with the next route matching the request, in
+// precedence order. Requests passed on by every matching route get a 404.
+//
+// The middleware of the routers enclosing both routes runs once: the next route
+// continues inside it with the middleware its chain does not share, such as
+// that of another group, its param handlers and its route middleware.
+var ErrNextRoute = errors.New("expressgo: next route")
+
 // Handler is a function that implements the Handler interface
 type Handler interface {
 	ServeHTTP(*Request, *Response) error
@@ -22,22 +34,33 @@ func (f HandlerFunc) ServeHTTP(req *Request, res *Response) error {
 // WrapHandler Convert the standard http.Handler to a Handler that returns an error
 func WrapHandler(h http.Handler) Handler {
 	return HandlerFunc(func(req *Request, res *Response) error {
-		h.ServeHTTP(res.ResponseWriter, req.Request)
+		h.ServeHTTP(res, req.Request)
 		return nil
 	})
 }
 
 type Router struct {
-	routes        map[string]map[string]Handler
-	middlewares   []Middleware
-	errorHandlers []ErrorHandlerFunc
+	parent         *Router
+	prefix         string
+	table          *routeTable
+	middlewares    []link
+	errorHandlers  []ErrorHandlerFunc
+	paramHandlers  map[string][]ParamHandler
+	errorTemplates map[string]ErrorTemplate
+
+	// changes counts the middleware and param handlers added, to recompile the chains depending on them
+	changes uint64
 }
 
-func NewRouter() *Router {
+// NewRouter creates a router configured by the given options
+func NewRouter(options ...Option) *Router {
 	r := &Router{
-		routes:        make(map[string]map[string]Handler),
+		table:         newRouteTable(),
 		errorHandlers: []ErrorHandlerFunc{},
 	}
+	for _, option := range options {
+		option(&r.table.options)
+	}
 	// register default error handlers
 	r.RegisterErrorHandler(DefaultFallbackErrorHandler)
 	r.RegisterErrorHandler(DefaultUnauthorizedErrorHandler)
@@ -46,7 +69,25 @@ func NewRouter() *Router {
 	return r
 }
 
-// RegisterErrorHandler register an error handler
+// Group returns a sub-router whose routes are registered under prefix.
+//
+// The group shares the route table of its parent, and its routes run the
+// parent's middleware followed by the given middleware and any middleware
+// later added with Use on the group. Groups can be nested.
+func (rt *Router) Group(prefix string, middleware ...Middleware) *Router {
+	return &Router{
+		parent:      rt,
+		prefix:      strings.TrimRight(rt.fullPath(prefix), "/"),
+		table:       rt.table,
+		middlewares: links(middleware),
+	}
+}
+
+// RegisterErrorHandler register an error handler.
+//
+// Error handlers registered on a group only handle the errors of the group's
+// routes. They are consulted before the error handlers of the enclosing routers,
+// which they reach by calling next.
 func (rt *Router) RegisterErrorHandler(handlerFunc ErrorHandlerFunc) {
 	// add at the beginning of the handler chain
 	rt.errorHandlers = append([]ErrorHandlerFunc{handlerFunc}, rt.errorHandlers...)
@@ -54,20 +95,38 @@ func (rt *Router) RegisterErrorHandler(handlerFunc ErrorHandlerFunc) {
 
 // HandleError handles errors
 func (rt *Router) HandleError(err error, req *Request, res *Response) {
-	if len(rt.errorHandlers) == 0 {
-		// use default error handlers if no error handlers
-		rt.errorHandlers = []ErrorHandlerFunc{DefaultNotFoundErrorHandler, DefaultMethodNotAllowedErrorHandler}
+	rt.handleError(nil, err, req, res)
+}
+
+// handleError passes err to the given route error handlers, followed by the error handlers of the router and
+// of all its parents, innermost first
+func (rt *Router) handleError(routeHandlers []ErrorHandlerFunc, err error, req *Request, res *Response) {
+	req.router = rt
+	if res.Committed() {
+		// the status and headers are already sent, so error handlers can only log or clean up
+		res.discard = true
+	}
+
+	handlers := routeHandlers[:len(routeHandlers):len(routeHandlers)]
+	for r := rt; r != nil; r = r.parent {
+		handlers = append(handlers, r.errorHandlers...)
+		if r.parent == nil && len(r.errorHandlers) == 0 {
+			// use default error handlers if no error handlers
+			handlers = append(handlers, DefaultNotFoundErrorHandler, DefaultMethodNotAllowedErrorHandler)
+		}
 	}
 
 	var currentHandlerIndex = 0
 	var next func(error)
 	next = func(err error) {
-		if currentHandlerIndex >= len(rt.errorHandlers) {
+		if currentHandlerIndex >= len(handlers) {
 			// use default error handler if no error handlers
-			http.Error(res, err.Error(), http.StatusInternalServerError)
+			if !res.Committed() {
+				http.Error(res, err.Error(), http.StatusInternalServerError)
+			}
 			return
 		}
-		handler := rt.errorHandlers[currentHandlerIndex]
+		handler := handlers[currentHandlerIndex]
 		currentHandlerIndex++
 		handler(err, req, res, next)
 	}
@@ -77,44 +136,146 @@ func (rt *Router) HandleError(err error, req *Request, res *Response) {
 
 // Use adds middleware to the router
 func (rt *Router) Use(middleware ...Middleware) {
-	rt.middlewares = append(rt.middlewares, middleware...)
+	rt.middlewares = append(rt.middlewares, links(middleware)...)
+	rt.changes++
 }
 
-// Handle registers a new route with a matcher for the URL path and method
-func (rt *Router) Handle(path string, method string, handler Handler) {
-	path = strings.Trim(path, "/")
-	if path == "" {
-		path = "/"
+// UseAround adds middleware to the router whose next returns the downstream error.
+// It runs in the order it is added, interleaved with the middleware added with Use.
+func (rt *Router) UseAround(middleware ...AroundMiddleware) {
+	for _, mw := range middleware {
+		rt.middlewares = append(rt.middlewares, link{around: mw})
 	}
-	if _, ok := rt.routes[path]; !ok {
-		rt.routes[path] = make(map[string]Handler)
+	rt.changes++
+}
+
+// Handle registers a new route with a matcher for the URL path and method.
+//
+// A path segment starting with ":" is a named parameter matching exactly one
+// segment, and a final segment starting with "*" is a catch-all matching the
+// rest of the path. A parameter can also be written as "{name}" or, to restrict
+// the values it matches, "{name:constraint}" where constraint is one of the
+// types int, uuid, alpha and slug or a regular expression. Requests whose value
+// does not satisfy the constraint fall through to the other routes.
+//
+// When several routes match a request, static segments take precedence over
+// parameters, constrained parameters over unconstrained ones, and parameters
+// over catch-alls. Constrained parameters are tried in the order they were
+// registered, so of two constraints matching the same value, like int and
+// "[0-9a-f]+", the first registered wins. Routes may name the same parameter
+// differently for different methods, as in "/users/:id" and "/users/:userId",
+// but Handle panics if such a route is registered for a method the other
+// already handles. This includes parameters with equivalent constraints, like
+// "{id:int}" and "{uid:\d+}".
+//
+// The last of handlers is the route handler and any before it are route
+// middleware, run in order after the router middleware. See Get for the
+// accepted handler and middleware types.
+//
+// The returned Route can be named for reverse URL generation with Router.URL.
+func (rt *Router) Handle(path string, method string, handlers ...any) *Route {
+	return rt.Route(path).Handle(method, handlers...)
+}
+
+// handle registers the endpoint for the method of the full path pattern
+func (rt *Router) handle(pattern string, method string, ep *endpoint) {
+	rt.table.update(func(s *routeSnapshot) {
+		s.tree.insert(pattern).handle(pattern, method, ep)
+	})
+}
+
+// Remove unregisters the route for the method of path, relative to the router's
+// prefix, and reports whether such a route existed. Routes registered with All
+// are removed with the method "ALL", as reported by Routes. The route's name is
+// released once no method of its path is left.
+//
+// Routes can be registered and removed while the router is serving requests.
+// Requests already dispatched finish with the handler they matched. Middleware,
+// error handlers and options must be set up before serving.
+func (rt *Router) Remove(method string, path string) bool {
+	if method == "ALL" {
+		method = methodAll
 	}
+	pattern := rt.fullPath(path)
 
-	rt.routes[path][method] = handler
+	var removed, kept bool
+	rt.table.update(func(s *routeSnapshot) {
+		tree := &node{}
+		s.tree.walk(func(l *leaf) {
+			if l.pattern == pattern {
+				if l.handlers[method] != nil {
+					removed = true
+					if l = l.without(method); l == nil {
+						return
+					}
+				}
+				kept = true
+			}
+			n := tree.insert(l.pattern)
+			n.leaves = append(n.leaves, l)
+		})
+		if !removed {
+			return
+		}
+		s.tree = tree
+
+		if !kept {
+			s.names = maps.Clone(s.names)
+			for name, p := range s.names {
+				if p == pattern {
+					delete(s.names, name)
+				}
+			}
+		}
+	})
+	return removed
 }
 
 // ServeHTTP handles incoming HTTP requests and dispatches them to the registered handlers.
 func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	req := NewRequest(r)
+	if info, ok := r.Context().Value(mountContextKey{}).(*mountInfo); ok {
+		req.params = append(req.params, info.params...)
+	}
 	res := NewResponse(w)
+	res.request = req
+
+	rt.serve(req, res)
+}
 
-	path := strings.Trim(req.URL.Path, "/")
-	if path == "" {
-		path = "/"
+// serve dispatches the request to the host router matching it, or else to the routes of this router
+func (rt *Router) serve(req *Request, res *Response) {
+	if host, params := rt.table.matchHost(req.Host, req.URL.Path); host != nil {
+		req.params = append(req.params, params...)
+		host.serve(req, res)
+		return
 	}
+
+	if target, ok := rt.table.redirectPath(req.URL.Path); ok {
+		redirect(req, res, target)
+		return
+	}
+
+	path := rt.table.routePath(req.URL.Path)
 	method := req.Method
 
-	// check full path
-	if methodHandlers, ok := rt.routes[path]; ok {
-		if h, ok := methodHandlers[method]; ok {
-			handler := rt.applyMiddleware(h)
-			if err := handler.ServeHTTP(req, res); err != nil {
-				rt.HandleError(err, req, res)
-			}
-			return
-		}
+	req.routing = routing{router: rt, path: path, method: method, params: len(req.params), writer: res.ResponseWriter}
+	ep, headFromGet, matched := rt.table.findRoute(path, method, 0, &req.params)
+	if ep != nil {
+		dispatch(ep, headFromGet, req, res)
+		return
+	}
+
+	if matched != nil && method == http.MethodOptions {
+		// run the middleware of the router owning the route, such as CORS middleware of a group
+		dispatch(&endpoint{router: matched.router(), handler: optionsHandler(rt.allowedMethods(path))}, false, req, res)
+		return
+	}
+
+	if matched != nil {
 		// 405
-		rt.HandleError(e.ErrorTypeMethodNotAllowed, req, res)
+		res.Header().Set("Allow", rt.allowedMethods(path))
+		matched.router().HandleError(e.ErrorTypeMethodNotAllowed, req, res)
 		return
 	}
 
@@ -122,21 +283,191 @@ func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	rt.HandleError(e.ErrorTypeNotFound, req, res)
 }
 
-func (rt *Router) applyMiddleware(handler Handler) Handler {
-	h := handler
-	for i := len(rt.middlewares) - 1; i >= 0; i-- {
-		mw := rt.middlewares[i]
-		currentHandler := h
-		h = HandlerFunc(func(r *Request, w *Response) error {
-			var err error
-			next := func() {
-				err = currentHandler.ServeHTTP(r, w)
+// findRoute looks up the route at index n among the routes handling method for
+// path, in precedence order, and appends its parameters to params. first is the
+// first route matching path whatever its methods, if any.
+func (t *routeTable) findRoute(path string, method string, n int, params *[]pathParam) (ep *endpoint, headFromGet bool, first *leaf) {
+	t.lookup(path, params, func(l *leaf) bool {
+		if first == nil {
+			first = l
+		}
+		if ep, headFromGet = l.endpoint(method); ep == nil {
+			return false
+		}
+		if n > 0 {
+			n--
+			ep = nil
+			return false
+		}
+		return true
+	})
+	return ep, headFromGet, first
+}
+
+// routing is the state of the route lookup of a request, kept to pass the
+// request on to the next route matching it
+type routing struct {
+	router *Router
+	path   string
+	method string
+
+	// index is the index of the current route among the routes handling the method
+	index int
+	// params is the number of request parameters captured before those of the route, by a host router
+	params      int
+	endpoint    *endpoint
+	headFromGet bool
+	writer      http.ResponseWriter
+}
+
+// routeCandidate is the route a request is passed on to with ErrNextRoute
+type routeCandidate struct {
+	endpoint    *endpoint
+	headFromGet bool
+
+	// shared is the number of middleware at the start of its chain that it shares with the current route
+	shared int
+}
+
+// nextRoute looks up the route after the current one handling the request, and
+// replaces the parameters of the request with its own. It returns nil if there
+// is none.
+func (r *Request) nextRoute() *routeCandidate {
+	rt := &r.routing
+	if rt.router == nil {
+		return nil
+	}
+
+	r.params = r.params[:rt.params]
+	rt.index++
+	ep, headFromGet, _ := rt.router.table.findRoute(rt.path, rt.method, rt.index, &r.params)
+	if ep == nil {
+		return nil
+	}
+	return &routeCandidate{endpoint: ep, headFromGet: headFromGet, shared: sharedLinks(rt.endpoint.router, ep.router)}
+}
+
+// dispatch runs the endpoint and handles the error of the route that served
+// the request, which is another one if it was passed on with ErrNextRoute.
+// Requests passed on by every route matching them get a 404.
+func dispatch(ep *endpoint, headFromGet bool, req *Request, res *Response) {
+	req.routing.endpoint, req.routing.headFromGet = ep, headFromGet
+	if headFromGet {
+		res.ResponseWriter = headResponseWriter{res.ResponseWriter}
+	}
+
+	err := ep.chain().ServeHTTP(req, res)
+	if errors.Is(err, ErrNextRoute) {
+		res.ResponseWriter = req.routing.writer
+		req.routing.router.HandleError(e.ErrorTypeNotFound, req, res)
+		return
+	}
+	if err != nil {
+		ep = req.routing.endpoint
+		ep.router.handleError(ep.errorHandlers(), err, req, res)
+	}
+}
+
+// allowedMethods returns the value of the Allow header for path, listing the methods of every route matching it
+func (rt *Router) allowedMethods(path string) string {
+	methods := map[string]bool{http.MethodOptions: true}
+	var params []pathParam
+	rt.table.lookup(path, &params, func(l *leaf) bool {
+		for method := range l.handlers {
+			if method != methodAll {
+				methods[method] = true
 			}
-			if err := mw(r, w, next); err != nil {
-				return err
+		}
+		if l.handlers[http.MethodGet] != nil {
+			methods[http.MethodHead] = true
+		}
+		return false
+	})
+
+	allowed := make([]string, 0, len(methods))
+	for method := range methods {
+		allowed = append(allowed, method)
+	}
+	sort.Strings(allowed)
+	return strings.Join(allowed, ", ")
+}
+
+// optionsHandler answers OPTIONS requests for routes without an OPTIONS handler of their own
+func optionsHandler(allow string) HandlerFunc {
+	return func(_ *Request, res *Response) error {
+		res.Header().Set("Allow", allow)
+		res.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
+
+// headResponseWriter discards the body written by a GET handler serving a HEAD request
+type headResponseWriter struct {
+	http.ResponseWriter
+}
+
+func (w headResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+// Unwrap returns the writer of the GET handler, for http.ResponseController to flush it
+func (w headResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// fullPath returns the route path of path relative to the router's prefix
+func (rt *Router) fullPath(path string) string {
+	path = rt.table.routePath(path)
+	if path == "/" && rt.prefix != "" && !rt.table.options.strictSlash {
+		return rt.prefix
+	}
+	return rt.prefix + path
+}
+
+// routePath trims surrounding slashes so that "/users/" and "users" register and match the same route
+func routePath(path string) string {
+	return "/" + strings.Trim(path, "/")
+}
+
+// compileChain returns the chain of the middleware of the router and of all its parents, outermost first,
+// followed by the given route middleware and the handler
+func (rt *Router) compileChain(routeMiddlewares []link, handler Handler) *chain {
+	var middlewares []link
+	for r := rt; r != nil; r = r.parent {
+		middlewares = append(r.middlewares[:len(r.middlewares):len(r.middlewares)], middlewares...)
+	}
+	if rt.hasParamHandlers() {
+		middlewares = append(middlewares, link{middleware: rt.paramMiddleware})
+	}
+	middlewares = append(middlewares, routeMiddlewares...)
+
+	c := &chain{links: middlewares, handler: handler}
+	for _, l := range middlewares {
+		c.around = c.around || l.around != nil
+	}
+	return c
+}
+
+// sharedLinks returns the number of middleware the chains of the routes of a
+// and b start with in common, that of the routers enclosing both
+func sharedLinks(a, b *Router) int {
+	for r := b; r != nil; r = r.parent {
+		if r.owns(a) {
+			n := 0
+			for ; r != nil; r = r.parent {
+				n += len(r.middlewares)
 			}
-			return err
-		})
+			return n
+		}
+	}
+	return 0
+}
+
+// generation changes whenever middleware or param handlers are added to the router or one of its parents
+func (rt *Router) generation() uint64 {
+	var generation uint64
+	for r := rt; r != nil; r = r.parent {
+		generation += r.changes
 	}
-	return h
+	return generation
 }
@@ -1,6 +1,7 @@
 package expressgo
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -8,6 +9,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/mikaeloduh/expressgo/e"
 )
 
 // Handler functions remain the same
@@ -147,3 +150,520 @@ func TestMiddleware(t *testing.T) {
 		assert.Equal(t, "test passed", string(body))
 	})
 }
+
+// TestPathParams verifies that ":param" segments are matched and exposed through Request.Param
+func TestPathParams(t *testing.T) {
+	route := NewRouter()
+
+	route.Handle("/users/:id", http.MethodGet, HandlerFunc(func(req *Request, res *Response) error {
+		fmt.Fprintf(res, "user %s", req.Param("id"))
+		return nil
+	}))
+	route.Handle("/users/:id/posts/:postId", http.MethodGet, HandlerFunc(func(req *Request, res *Response) error {
+		fmt.Fprintf(res, "user %s post %s", req.Param("id"), req.Param("postId"))
+		return nil
+	}))
+	route.Handle("/users/profile", http.MethodGet, HandlerFunc(userProfileHandler))
+	route.Handle("/users/:userId", http.MethodDelete, HandlerFunc(func(req *Request, res *Response) error {
+		fmt.Fprintf(res, "deleted %s%s", req.Param("userId"), req.Param("id"))
+		return nil
+	}))
+
+	tests := []struct {
+		method       string
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{"GET", "/users/42", http.StatusOK, "user 42"},
+		{"GET", "/users/42/", http.StatusOK, "user 42"},
+		{"GET", "/users/42/posts/7", http.StatusOK, "user 42 post 7"},
+		{"DELETE", "/users/42", http.StatusOK, "deleted 42"},
+		{"GET", "/users/profile", http.StatusOK, "User profile page"},
+		{"POST", "/users/42", http.StatusMethodNotAllowed, "Method \"POST\" is not allowed on path \"users/42\""},
+		{"GET", "/users/42/posts", http.StatusNotFound, "Cannot find the path \"/users/42/posts\""},
+	}
+
+	for _, tc := range tests {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		rr := httptest.NewRecorder()
+
+		route.ServeHTTP(rr, req)
+
+		assert.Equal(t, tc.expectedCode, rr.Code, "Unexpected status code for path %s", tc.path)
+		assert.Equal(t, tc.expectedBody, rr.Body.String(), "Unexpected response body for path %s", tc.path)
+	}
+}
+
+// TestRequestParams verifies that Request.Params returns every captured parameter
+func TestRequestParams(t *testing.T) {
+	route := NewRouter()
+
+	var params map[string]string
+	route.Handle("/users/:id/posts/:postId", http.MethodGet, HandlerFunc(func(req *Request, _ *Response) error {
+		params = req.Params()
+		return nil
+	}))
+
+	route.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42/posts/7", nil))
+
+	assert.Equal(t, map[string]string{"id": "42", "postId": "7"}, params)
+}
+
+// TestRoutePrecedence verifies that static routes win over parameters, and parameters over catch-alls
+func TestRoutePrecedence(t *testing.T) {
+	route := NewRouter()
+
+	route.Handle("/files/readme", http.MethodGet, HandlerFunc(func(_ *Request, res *Response) error {
+		fmt.Fprintf(res, "static")
+		return nil
+	}))
+	route.Handle("/files/:name", http.MethodGet, HandlerFunc(func(req *Request, res *Response) error {
+		fmt.Fprintf(res, "param %s", req.Param("name"))
+		return nil
+	}))
+	route.Handle("/files/*filepath", http.MethodGet, HandlerFunc(func(req *Request, res *Response) error {
+		fmt.Fprintf(res, "catch-all %s", req.Param("filepath"))
+		return nil
+	}))
+
+	tests := []struct {
+		path         string
+		expectedBody string
+	}{
+		{"/files/readme", "static"},
+		{"/files/license", "param license"},
+		{"/files/css/app.css", "catch-all css/app.css"},
+	}
+
+	for _, tc := range tests {
+		rr := httptest.NewRecorder()
+		route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code, "Unexpected status code for path %s", tc.path)
+		assert.Equal(t, tc.expectedBody, rr.Body.String(), "Unexpected response body for path %s", tc.path)
+	}
+}
+
+// tagMiddleware appends the tag to the X-Trace response header
+func tagMiddleware(tag string) Middleware {
+	return func(_ *Request, res *Response, next func()) error {
+		res.Header().Add("X-Trace", tag)
+		next()
+		return nil
+	}
+}
+
+// TestGroup verifies that groups prefix their routes and run the middleware of every enclosing router
+func TestGroup(t *testing.T) {
+	route := NewRouter()
+	route.Use(tagMiddleware("root"))
+
+	route.Handle("/health", http.MethodGet, HandlerFunc(func(_ *Request, res *Response) error {
+		fmt.Fprintf(res, "ok")
+		return nil
+	}))
+
+	api := route.Group("/api", tagMiddleware("api"))
+	api.Handle("/users/:id", http.MethodGet, HandlerFunc(func(req *Request, res *Response) error {
+		fmt.Fprintf(res, "user %s", req.Param("id"))
+		return nil
+	}))
+
+	admin := api.Group("admin")
+	admin.Use(tagMiddleware("admin"))
+	admin.Handle("/", http.MethodGet, HandlerFunc(func(_ *Request, res *Response) error {
+		fmt.Fprintf(res, "dashboard")
+		return nil
+	}))
+
+	tests := []struct {
+		path          string
+		expectedBody  string
+		expectedTrace []string
+	}{
+		{"/health", "ok", []string{"root"}},
+		{"/api/users/42", "user 42", []string{"root", "api"}},
+		{"/api/admin", "dashboard", []string{"root", "api", "admin"}},
+	}
+
+	for _, tc := range tests {
+		rr := httptest.NewRecorder()
+		route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code, "Unexpected status code for path %s", tc.path)
+		assert.Equal(t, tc.expectedBody, rr.Body.String(), "Unexpected response body for path %s", tc.path)
+		assert.Equal(t, tc.expectedTrace, rr.Header().Values("X-Trace"), "Unexpected middleware for path %s", tc.path)
+	}
+}
+
+// TestGroupErrorHandling verifies that errors from group routes reach the error handlers of the owning router
+func TestGroupErrorHandling(t *testing.T) {
+	route := NewRouter()
+	route.RegisterErrorHandler(func(err error, _ *Request, res *Response, _ func(error)) {
+		res.WriteHeader(http.StatusTeapot)
+		fmt.Fprintf(res, "handled: %v", err)
+	})
+
+	api := route.Group("/api", testMiddleware)
+	api.Handle("/test", http.MethodGet, HandlerFunc(func(_ *Request, res *Response) error {
+		fmt.Fprintf(res, "test passed")
+		return nil
+	}))
+
+	rr := httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/test", nil))
+
+	assert.Equal(t, http.StatusTeapot, rr.Code)
+	assert.Equal(t, "handled: missing or invalid X-Test header", rr.Body.String())
+}
+
+// TestScopedErrorHandlers verifies that group and route error handlers are consulted innermost first
+func TestScopedErrorHandlers(t *testing.T) {
+	errFailed := errors.New("failed")
+	failing := func(*Request, *Response) error { return errFailed }
+	writeError := func(format string) ErrorHandlerFunc {
+		return func(err error, _ *Request, res *Response, _ func(error)) {
+			res.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(res, format, err)
+		}
+	}
+
+	route := NewRouter()
+	route.RegisterErrorHandler(writeError("global: %v"))
+	route.Get("/home", failing)
+
+	api := route.Group("/api")
+	api.RegisterErrorHandler(writeError(`{"error":%q}`))
+	api.Get("/users", failing)
+	api.Route("/reports").
+		RegisterErrorHandler(func(err error, req *Request, res *Response, next func(error)) {
+			res.Header().Set("X-Report-Error", "1")
+			next(err)
+		}).
+		Get(failing)
+
+	web := route.Group("/web")
+	web.RegisterErrorHandler(writeError("<p>%v</p>"))
+	web.Get("/page", failing)
+	web.Group("/raw").Get("/page", func(*Request, *Response) error { return e.ErrorTypeUnauthorized })
+
+	tests := []struct {
+		path         string
+		expectedBody string
+	}{
+		{"/home", "global: failed"},
+		{"/api/users", `{"error":"failed"}`},
+		{"/api/reports", `{"error":"failed"}`},
+		{"/web/page", "<p>failed</p>"},
+		{"/web/raw/page", "<p>Unauthorized</p>"},
+		{"/api/missing", "global: Not Found"},
+	}
+
+	for _, tc := range tests {
+		rr := httptest.NewRecorder()
+		route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		assert.Equal(t, tc.expectedBody, rr.Body.String(), "Unexpected body for %s", tc.path)
+	}
+
+	rr := httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/reports", nil))
+	assert.Equal(t, "1", rr.Header().Get("X-Report-Error"))
+}
+
+// TestAllowHeader verifies that 405 responses list the methods of the matched route in the Allow header
+func TestAllowHeader(t *testing.T) {
+	route := NewRouter()
+	route.Get("/user", getUserHandler)
+	route.Post("/user", postUserHandler)
+
+	rr := httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/user", nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS, POST", rr.Header().Get("Allow"))
+}
+
+// TestAutomaticOptions verifies that OPTIONS requests are answered unless the route handles them itself
+func TestAutomaticOptions(t *testing.T) {
+	route := NewRouter()
+	route.Use(tagMiddleware("root"))
+	route.Get("/user", getUserHandler)
+	route.Put("/user", postUserHandler)
+	route.Get("/custom", getUserHandler)
+	route.Options("/custom", func(_ *Request, res *Response) error {
+		fmt.Fprint(res, "custom options")
+		return nil
+	})
+
+	rr := httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodOptions, "/user", nil))
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS, PUT", rr.Header().Get("Allow"))
+	assert.Equal(t, []string{"root"}, rr.Header().Values("X-Trace"))
+	assert.Empty(t, rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodOptions, "/custom", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "custom options", rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodOptions, "/missing", nil))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	api := route.Group("/api", tagMiddleware("api"))
+	api.Get("/users", getUserHandler)
+
+	rr = httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodOptions, "/api/users", nil))
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS", rr.Header().Get("Allow"))
+	assert.Equal(t, []string{"root", "api"}, rr.Header().Values("X-Trace"))
+}
+
+// TestHeadFromGet verifies that HEAD requests are served by the GET handler without a body
+func TestHeadFromGet(t *testing.T) {
+	route := NewRouter()
+	route.Get("/user", func(_ *Request, res *Response) error {
+		res.Header().Set("X-User", "1")
+		fmt.Fprint(res, "Retrieve user information")
+		return nil
+	})
+
+	rr := httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodHead, "/user", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "1", rr.Header().Get("X-User"))
+	assert.Empty(t, rr.Body.String())
+}
+
+// TestPathPolicy verifies the trailing slash, path cleaning and case matching options
+func TestPathPolicy(t *testing.T) {
+	type testCase struct {
+		method           string
+		path             string
+		expectedCode     int
+		expectedBody     string
+		expectedLocation string
+	}
+
+	register := func(route *Router) {
+		route.Get("/users", getUserHandler)
+		route.Post("/users", postUserHandler)
+		route.Get("/admin/", func(_ *Request, res *Response) error {
+			fmt.Fprint(res, "admin")
+			return nil
+		})
+		route.Get("/users/:name", func(req *Request, res *Response) error {
+			fmt.Fprintf(res, "user %s", req.Param("name"))
+			return nil
+		})
+	}
+
+	run := func(t *testing.T, route *Router, tests []testCase) {
+		for _, tc := range tests {
+			rr := httptest.NewRecorder()
+			route.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, nil))
+
+			assert.Equal(t, tc.expectedCode, rr.Code, "Unexpected status code for %s %s", tc.method, tc.path)
+			if tc.expectedLocation != "" {
+				assert.Equal(t, tc.expectedLocation, rr.Header().Get("Location"), "Unexpected redirect for %s %s", tc.method, tc.path)
+			} else {
+				assert.Equal(t, tc.expectedBody, rr.Body.String(), "Unexpected response body for %s %s", tc.method, tc.path)
+			}
+		}
+	}
+
+	t.Run("lenient", func(t *testing.T) {
+		route := NewRouter()
+		register(route)
+
+		run(t, route, []testCase{
+			{"GET", "/users", http.StatusOK, "Retrieve user information", ""},
+			{"GET", "/users/", http.StatusOK, "Retrieve user information", ""},
+			{"GET", "/admin", http.StatusOK, "admin", ""},
+			{"GET", "/Users", http.StatusNotFound, "Cannot find the path \"/Users\"", ""},
+		})
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		route := NewRouter(WithStrictSlash())
+		register(route)
+
+		run(t, route, []testCase{
+			{"GET", "/users", http.StatusOK, "Retrieve user information", ""},
+			{"GET", "/users/", http.StatusNotFound, "Cannot find the path \"/users/\"", ""},
+			{"GET", "/admin/", http.StatusOK, "admin", ""},
+			{"GET", "/admin", http.StatusNotFound, "Cannot find the path \"/admin\"", ""},
+		})
+	})
+
+	t.Run("strict with redirect", func(t *testing.T) {
+		route := NewRouter(WithStrictSlash(), WithRedirectTrailingSlash())
+		register(route)
+
+		run(t, route, []testCase{
+			{"GET", "/users", http.StatusOK, "Retrieve user information", ""},
+			{"GET", "/users/", http.StatusMovedPermanently, "", "/users"},
+			{"POST", "/users/", http.StatusPermanentRedirect, "", "/users"},
+			{"GET", "/admin", http.StatusMovedPermanently, "", "/admin/"},
+			{"GET", "/missing/", http.StatusNotFound, "Cannot find the path \"/missing/\"", ""},
+		})
+	})
+
+	t.Run("lenient with redirect", func(t *testing.T) {
+		route := NewRouter(WithRedirectTrailingSlash())
+		register(route)
+
+		run(t, route, []testCase{
+			{"GET", "/users", http.StatusOK, "Retrieve user information", ""},
+			{"GET", "/users/?page=2", http.StatusMovedPermanently, "", "/users?page=2"},
+			{"GET", "/admin", http.StatusOK, "admin", ""},
+		})
+	})
+
+	t.Run("clean path", func(t *testing.T) {
+		route := NewRouter(WithCleanPath())
+		register(route)
+
+		run(t, route, []testCase{
+			{"GET", "//users/../admin", http.StatusMovedPermanently, "", "/admin"},
+			{"GET", "/users/./bob/", http.StatusMovedPermanently, "", "/users/bob/"},
+			{"POST", "/admin/../users", http.StatusPermanentRedirect, "", "/users"},
+			{"GET", "/users/bob", http.StatusOK, "user bob", ""},
+		})
+	})
+
+	t.Run("case insensitive", func(t *testing.T) {
+		route := NewRouter(WithCaseInsensitive())
+		route.Get("/users/profile", userProfileHandler)
+		route.Get("/Files/:name", func(req *Request, res *Response) error {
+			fmt.Fprintf(res, "file %s", req.Param("name"))
+			return nil
+		})
+
+		run(t, route, []testCase{
+			{"GET", "/USERS/Profile", http.StatusOK, "User profile page", ""},
+			{"GET", "/files/ReadMe", http.StatusOK, "file ReadMe", ""},
+		})
+	})
+}
+
+// TestPathPolicyMounted verifies that redirects of a mounted router keep the mount prefix
+func TestPathPolicyMounted(t *testing.T) {
+	admin := NewRouter(WithCleanPath())
+	admin.Get("/stats", homeHandler)
+
+	route := NewRouter()
+	route.Mount("/admin", admin)
+
+	rr := httptest.NewRecorder()
+	route.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/x/../stats", nil))
+
+	assert.Equal(t, http.StatusMovedPermanently, rr.Code)
+	assert.Equal(t, "/admin/stats", rr.Header().Get("Location"))
+}
+
+func TestNextRoute(t *testing.T) {
+	var paramCalls, middlewareCalls int
+	route := NewRouter()
+	route.Use(func(req *Request, res *Response, next func()) error {
+		middlewareCalls++
+		next()
+		return nil
+	})
+	route.Param("id", func(req *Request, res *Response, next func(), id string) error {
+		paramCalls++
+		next()
+		return nil
+	})
+
+	adminOnly := func(req *Request, res *Response, next func()) error {
+		if req.Header.Get("X-Admin") == "" {
+			return ErrNextRoute
+		}
+		next()
+		return nil
+	}
+	route.Get("/users/new", adminOnly, func(req *Request, res *Response) error {
+		_, _ = res.Write([]byte("new user form"))
+		return nil
+	})
+	route.Get("/users/{id:alpha}", func(*Request, *Response) error { return ErrNextRoute })
+	route.Get("/users/:id", func(req *Request, res *Response) error {
+		if req.Param("id") == "skip" {
+			return ErrNextRoute
+		}
+		_, _ = res.Write([]byte("user " + req.Param("id")))
+		return nil
+	})
+	route.Get("/users/*rest", func(req *Request, res *Response) error {
+		_, _ = res.Write([]byte("rest " + req.Param("rest")))
+		return nil
+	})
+	route.Get("/items/:id", func(*Request, *Response) error { return ErrNextRoute })
+
+	tests := []struct {
+		path         string
+		admin        bool
+		expectedCode int
+		expectedBody string
+	}{
+		{"/users/new", true, http.StatusOK, "new user form"},
+		{"/users/new", false, http.StatusOK, "user new"},
+		{"/users/skip", false, http.StatusOK, "rest skip"},
+		{"/items/1", false, http.StatusNotFound, "Cannot find the path \"/items/1\""},
+	}
+
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.admin {
+			req.Header.Set("X-Admin", "1")
+		}
+		rr := httptest.NewRecorder()
+		route.ServeHTTP(rr, req)
+		assert.Equal(t, tc.expectedCode, rr.Code, "Unexpected status for %s", tc.path)
+		assert.Equal(t, tc.expectedBody, rr.Body.String(), "Unexpected body for %s", tc.path)
+	}
+
+	// the param handler ran once per request although two routes with the parameter were tried
+	assert.Equal(t, 3, paramCalls)
+	// the router middleware ran once per request although several routes were tried
+	assert.Equal(t, len(tests), middlewareCalls)
+}
+
+// TestNextRouteAcrossGroups verifies that only the middleware a route does not share with the skipped one runs again
+func TestNextRouteAcrossGroups(t *testing.T) {
+	route := NewRouter()
+	route.Use(tagMiddleware("root"))
+	beta := route.Group("/api", tagMiddleware("beta"))
+	beta.Get("/users", tagMiddleware("route"), func(*Request, *Response) error { return ErrNextRoute })
+	api := route.Group("/api", tagMiddleware("api"))
+	api.UseAround(func(req *Request, res *Response, next func() error) error {
+		res.Header().Add("X-Trace", "around")
+		return next()
+	})
+	api.Get("/:resource", func(req *Request, res *Response) error {
+		_, _ = res.Write([]byte("api " + req.Param("resource")))
+		return nil
+	})
+
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		rr := httptest.NewRecorder()
+		route.ServeHTTP(rr, httptest.NewRequest(method, "/api/users", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code, "Unexpected status for %s", method)
+		assert.Equal(t, []string{"root", "beta", "route", "api", "around"}, rr.Header().Values("X-Trace"), "Unexpected middleware for %s", method)
+		if method == http.MethodGet {
+			assert.Equal(t, "api users", rr.Body.String())
+		} else {
+			assert.Empty(t, rr.Body.String())
+		}
+	}
+}
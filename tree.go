@@ -0,0 +1,419 @@
+package expressgo
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// nodeKind tells how a tree node matches the request path
+type nodeKind uint8
+
+const (
+	staticNode   nodeKind = iota // matches its prefix literally
+	paramNode                    // matches one path segment, e.g. ":id"
+	catchAllNode                 // matches the rest of the path, e.g. "*filepath"
+)
+
+// pathParam is a path parameter captured while matching a route
+type pathParam struct {
+	key   string
+	value string
+}
+
+// leaf holds everything registered for one route pattern. Patterns differing
+// only in their parameter names, such as "/users/:id" and "/users/:userId",
+// end at the same node and have a leaf each.
+type leaf struct {
+	pattern  string
+	names    []string
+	handlers map[string]*endpoint
+}
+
+// newLeaf creates a leaf for pattern without handlers
+func newLeaf(pattern string) *leaf {
+	return &leaf{pattern: pattern, names: paramNames(pattern), handlers: make(map[string]*endpoint)}
+}
+
+// endpoint returns the endpoint handling method. HEAD requests fall back to the
+// GET handler, reported by headFromGet, and every method falls back to All.
+func (l *leaf) endpoint(method string) (ep *endpoint, headFromGet bool) {
+	if ep = l.handlers[method]; ep != nil {
+		return ep, false
+	}
+	if method == http.MethodHead {
+		if ep = l.handlers[http.MethodGet]; ep != nil {
+			return ep, true
+		}
+	}
+	return l.handlers[methodAll], false
+}
+
+// router returns the router the route was registered on. Should its methods be
+// registered on different routers, the router of the first method in lexical order.
+func (l *leaf) router() *Router {
+	var first string
+	var rt *Router
+	for method, ep := range l.handlers {
+		if rt == nil || method < first {
+			first, rt = method, ep.router
+		}
+	}
+	return rt
+}
+
+// with returns a copy of the leaf with ep handling method
+func (l *leaf) with(method string, ep *endpoint) *leaf {
+	c := &leaf{pattern: l.pattern, names: l.names, handlers: maps.Clone(l.handlers)}
+	c.handlers[method] = ep
+	return c
+}
+
+// overlaps reports whether requests for method could be served by the leaf,
+// counting the fallbacks of HEAD to GET and of every method to All
+func (l *leaf) overlaps(method string) bool {
+	if l.handlers[method] != nil || l.handlers[methodAll] != nil || method == methodAll {
+		return true
+	}
+	return method == http.MethodHead && l.handlers[http.MethodGet] != nil ||
+		method == http.MethodGet && l.handlers[http.MethodHead] != nil
+}
+
+// without returns a copy of the leaf without the handler of method, or nil if no handler is left
+func (l *leaf) without(method string) *leaf {
+	if len(l.handlers) == 1 {
+		return nil
+	}
+	c := &leaf{pattern: l.pattern, names: l.names, handlers: maps.Clone(l.handlers)}
+	delete(c.handlers, method)
+	return c
+}
+
+// endpoint is a handler registered for one method of a route, along with the router owning it
+type endpoint struct {
+	router      *Router
+	route       *Route
+	handler     Handler
+	middlewares []link
+	compiled    atomic.Pointer[chain]
+}
+
+// chain returns the compiled middleware chain of the endpoint, compiling it
+// again if middleware was added to its routers or route since
+func (ep *endpoint) chain() *chain {
+	generation := ep.router.generation()
+	if ep.route != nil {
+		generation += ep.route.changes
+	}
+	if c := ep.compiled.Load(); c != nil && c.generation == generation {
+		return c
+	}
+
+	c := ep.router.compileChain(ep.routeMiddlewares(), ep.handler)
+	c.generation = generation
+	ep.compiled.Store(c)
+	return c
+}
+
+// errorHandlers returns the error handlers of the route of the endpoint
+func (ep *endpoint) errorHandlers() []ErrorHandlerFunc {
+	if ep.route == nil {
+		return nil
+	}
+	return ep.route.errorHandlers
+}
+
+// routeMiddlewares returns the middleware of the route followed by the middleware of the endpoint
+func (ep *endpoint) routeMiddlewares() []link {
+	if ep.route == nil || len(ep.route.middlewares) == 0 {
+		return ep.middlewares
+	}
+	return append(ep.route.middlewares[:len(ep.route.middlewares):len(ep.route.middlewares)], ep.middlewares...)
+}
+
+// node is a node of the compressed radix tree used to match routes.
+//
+// Static children are indexed by the first byte of their prefix, so a static
+// lookup never allocates. Parameter and catch-all children always start at a
+// segment boundary and are tried only after the static children, which gives
+// the precedence static > param > catch-all.
+type node struct {
+	kind       nodeKind
+	prefix     string
+	constraint *constraint
+	indices    string
+	children   []*node
+	params     []*node
+	catchAll   *node
+	leaves     []*leaf
+}
+
+// clone returns a shallow copy of the node that can be changed without affecting n
+func (n *node) clone() *node {
+	c := *n
+	c.children = append([]*node(nil), n.children...)
+	c.params = append([]*node(nil), n.params...)
+	return &c
+}
+
+// insert adds the pattern to the tree and returns the node that terminates it.
+// It panics when the pattern is malformed.
+//
+// The nodes below n on the way to the pattern are copied before they are
+// changed, so that a tree already in use is left untouched when n is a copy of
+// its root. The returned node is such a copy and can be changed as well.
+func (n *node) insert(pattern string) *node {
+	path := pattern
+	for path != "" {
+		i := strings.IndexAny(path, ":*{")
+		if i < 0 {
+			return n.insertStatic(path)
+		}
+		if i > 0 && path[i-1] != '/' {
+			panic(fmt.Sprintf("expressgo: wildcard in %q must start a path segment", pattern))
+		}
+		n = n.insertStatic(path[:i])
+
+		end := segmentEnd(path, i)
+		token := path[i:end]
+
+		if token[0] == '*' {
+			if end != len(path) {
+				panic(fmt.Sprintf("expressgo: catch-all %q must be the last segment of %q", token, pattern))
+			}
+			if n.catchAll == nil {
+				n.catchAll = &node{kind: catchAllNode, prefix: token}
+			} else {
+				n.catchAll = n.catchAll.clone()
+			}
+			return n.catchAll
+		}
+
+		_, c, err := parseParam(token)
+		if err != nil {
+			panic(fmt.Sprintf("expressgo: parameter %q in %q: %v", token, pattern, err))
+		}
+		n = n.insertParam(token, c)
+		path = path[end:]
+	}
+	return n
+}
+
+// insertParam returns the parameter child of n with the given constraint, adding it if needed.
+// Constrained parameters are kept before the unconstrained one so that they are tried first.
+func (n *node) insertParam(token string, c *constraint) *node {
+	for i, p := range n.params {
+		if p.constraint.equal(c) {
+			n.params[i] = p.clone()
+			return n.params[i]
+		}
+	}
+
+	child := &node{kind: paramNode, prefix: token, constraint: c}
+	last := len(n.params) - 1
+	if c != nil && last >= 0 && n.params[last].constraint == nil {
+		n.params = append(n.params[:last], child, n.params[last])
+	} else {
+		n.params = append(n.params, child)
+	}
+	return child
+}
+
+// handle registers ep for the method of pattern, n being the node terminating
+// pattern. It panics when another pattern ending at n, which names its
+// parameters differently, handles requests for the method too.
+func (n *node) handle(pattern string, method string, ep *endpoint) {
+	leaves := make([]*leaf, 0, len(n.leaves)+1)
+	added := false
+	for _, l := range n.leaves {
+		switch {
+		case l.pattern == pattern:
+			l = l.with(method, ep)
+			added = true
+		case l.overlaps(method):
+			panic(fmt.Sprintf("expressgo: route %q conflicts with existing %q", pattern, l.pattern))
+		}
+		leaves = append(leaves, l)
+	}
+	if !added {
+		leaves = append(leaves, newLeaf(pattern).with(method, ep))
+	}
+	n.leaves = leaves
+}
+
+// paramNames returns the names of the parameters and catch-all of pattern, in order
+func paramNames(pattern string) []string {
+	var names []string
+	path := pattern
+	for {
+		i := strings.IndexAny(path, ":*{")
+		if i < 0 {
+			return names
+		}
+		end := segmentEnd(path, i)
+		token := path[i:end]
+		if token[0] == '*' {
+			if token == "*" {
+				return append(names, "*")
+			}
+			return append(names, token[1:])
+		}
+		name, _, _ := parseParam(token)
+		names = append(names, name)
+		path = path[end:]
+	}
+}
+
+// segmentEnd returns the index of the end of the path segment starting at i,
+// skipping over slashes inside a braced parameter
+func segmentEnd(path string, i int) int {
+	depth := 0
+	for j := i; j < len(path); j++ {
+		switch path[j] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case '/':
+			if depth <= 0 {
+				return j
+			}
+		}
+	}
+	return len(path)
+}
+
+// insertStatic adds a literal path to the static children of n, splitting
+// existing nodes on their longest common prefix.
+func (n *node) insertStatic(path string) *node {
+	for path != "" {
+		i := strings.IndexByte(n.indices, path[0])
+		if i < 0 {
+			child := &node{kind: staticNode, prefix: path}
+			n.indices += path[:1]
+			n.children = append(n.children, child)
+			return child
+		}
+
+		child := n.children[i].clone()
+		n.children[i] = child
+		l := commonPrefix(child.prefix, path)
+		if l < len(child.prefix) {
+			split := &node{
+				kind:     staticNode,
+				prefix:   child.prefix[:l],
+				indices:  child.prefix[l : l+1],
+				children: []*node{child},
+			}
+			child.prefix = child.prefix[l:]
+			n.children[i] = split
+			child = split
+		}
+		n = child
+		path = path[l:]
+	}
+	return n
+}
+
+// lookup walks every route matching path in precedence order and calls visit
+// for each of them until visit returns true. Captured parameters are appended
+// to params, named after the parameters of the visited route's pattern; on a
+// false return they are removed again.
+func (n *node) lookup(path string, params *[]pathParam, visit func(*leaf) bool) bool {
+	return n.match(path, false, params, visit)
+}
+
+// match is lookup, optionally comparing static segments case-insensitively
+func (n *node) match(path string, fold bool, params *[]pathParam, visit func(*leaf) bool) bool {
+	if path == "" && n.visit(*params, visit) {
+		return true
+	}
+
+	if path != "" {
+		if fold {
+			for _, child := range n.children {
+				if len(path) >= len(child.prefix) && strings.EqualFold(path[:len(child.prefix)], child.prefix) &&
+					child.match(path[len(child.prefix):], fold, params, visit) {
+					return true
+				}
+			}
+		} else if i := strings.IndexByte(n.indices, path[0]); i >= 0 {
+			child := n.children[i]
+			if strings.HasPrefix(path, child.prefix) && child.match(path[len(child.prefix):], fold, params, visit) {
+				return true
+			}
+		}
+
+		if len(n.params) > 0 {
+			end := strings.IndexByte(path, '/')
+			if end < 0 {
+				end = len(path)
+			}
+			if end > 0 {
+				value := path[:end]
+				for _, p := range n.params {
+					if !p.constraint.match(value) {
+						continue
+					}
+					*params = append(*params, pathParam{value: value})
+					if p.match(path[end:], fold, params, visit) {
+						return true
+					}
+					*params = (*params)[:len(*params)-1]
+				}
+			}
+		}
+	}
+
+	if n.catchAll != nil && len(n.catchAll.leaves) > 0 {
+		*params = append(*params, pathParam{value: path})
+		if n.catchAll.visit(*params, visit) {
+			return true
+		}
+		*params = (*params)[:len(*params)-1]
+	}
+
+	return false
+}
+
+// visit calls visit for the leaves of n in order until it returns true, first
+// naming the trailing params after the parameters of the leaf's pattern
+func (n *node) visit(params []pathParam, visit func(*leaf) bool) bool {
+	for _, l := range n.leaves {
+		named := params[len(params)-len(l.names):]
+		for i, name := range l.names {
+			named[i].key = name
+		}
+		if visit(l) {
+			return true
+		}
+	}
+	return false
+}
+
+// commonPrefix returns the length of the longest common prefix of a and b
+func commonPrefix(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// walk calls fn for every leaf of the tree
+func (n *node) walk(fn func(*leaf)) {
+	for _, l := range n.leaves {
+		fn(l)
+	}
+	for _, child := range n.children {
+		child.walk(fn)
+	}
+	for _, p := range n.params {
+		p.walk(fn)
+	}
+	if n.catchAll != nil {
+		n.catchAll.walk(fn)
+	}
+}
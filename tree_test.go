@@ -0,0 +1,236 @@
+package expressgo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// match returns the pattern of the first route matching path and its captured parameters
+func match(tree *node, path string) (string, map[string]string) {
+	var params []pathParam
+	var pattern string
+	tree.lookup(path, &params, func(l *leaf) bool {
+		pattern = l.pattern
+		return true
+	})
+
+	captured := make(map[string]string)
+	for _, p := range params {
+		captured[p.key] = p.value
+	}
+	return pattern, captured
+}
+
+func newTestTree(patterns ...string) *node {
+	tree := &node{}
+	for _, p := range patterns {
+		n := tree.insert(p)
+		n.leaves = append(n.leaves, newLeaf(p))
+	}
+	return tree
+}
+
+func TestTreeMatch(t *testing.T) {
+	tree := newTestTree(
+		"/",
+		"/users",
+		"/users/new",
+		"/users/:id",
+		"/users/:id/posts/:postId",
+		"/usernames",
+		"/files/*filepath",
+		"/static/*",
+	)
+
+	tests := []struct {
+		path            string
+		expectedPattern string
+		expectedParams  map[string]string
+	}{
+		{"/", "/", map[string]string{}},
+		{"/users", "/users", map[string]string{}},
+		{"/usernames", "/usernames", map[string]string{}},
+		{"/users/new", "/users/new", map[string]string{}},
+		{"/users/42", "/users/:id", map[string]string{"id": "42"}},
+		{"/users/newer", "/users/:id", map[string]string{"id": "newer"}},
+		{"/users/42/posts/7", "/users/:id/posts/:postId", map[string]string{"id": "42", "postId": "7"}},
+		{"/files/css/app.css", "/files/*filepath", map[string]string{"filepath": "css/app.css"}},
+		{"/static/js/app.js", "/static/*", map[string]string{"*": "js/app.js"}},
+		{"/user", "", map[string]string{}},
+		{"/users/42/posts", "", map[string]string{}},
+	}
+
+	for _, tc := range tests {
+		pattern, params := match(tree, tc.path)
+		assert.Equal(t, tc.expectedPattern, pattern, "Unexpected route for path %s", tc.path)
+		assert.Equal(t, tc.expectedParams, params, "Unexpected params for path %s", tc.path)
+	}
+}
+
+func TestTreePrecedenceBacktracking(t *testing.T) {
+	tree := newTestTree(
+		"/a/b/c",
+		"/a/:x/d",
+		"/a/*rest",
+	)
+
+	// the static branch "/a/b" matches the prefix but not the remainder, so the param branch is tried next
+	pattern, params := match(tree, "/a/b/d")
+	assert.Equal(t, "/a/:x/d", pattern)
+	assert.Equal(t, map[string]string{"x": "b"}, params)
+
+	// neither static nor param branch matches, so the catch-all wins
+	pattern, params = match(tree, "/a/b/e")
+	assert.Equal(t, "/a/*rest", pattern)
+	assert.Equal(t, map[string]string{"rest": "b/e"}, params)
+}
+
+func TestTreeVisitsAllCandidates(t *testing.T) {
+	tree := newTestTree("/a/*rest", "/a/:x", "/a/b")
+
+	var visited []string
+	var params []pathParam
+	tree.lookup("/a/b", &params, func(l *leaf) bool {
+		visited = append(visited, l.pattern)
+		return false
+	})
+
+	assert.Equal(t, []string{"/a/b", "/a/:x", "/a/*rest"}, visited)
+	assert.Empty(t, params)
+}
+
+func TestTreeConflicts(t *testing.T) {
+	tests := []struct {
+		existing       string
+		existingMethod string
+		pattern        string
+		method         string
+		conflict       bool
+	}{
+		{"/users/:id", http.MethodGet, "/users/:name", http.MethodGet, true},
+		{"/users/:id", http.MethodGet, "/users/:userId", http.MethodDelete, false},
+		{"/users/:id", http.MethodGet, "/users/:userId", http.MethodHead, true},
+		{"/users/:id", methodAll, "/users/:userId", http.MethodDelete, true},
+		{"/files/*path", http.MethodGet, "/files/*name", http.MethodGet, true},
+		{"/files/*path", http.MethodGet, "/files/*name", http.MethodPut, false},
+		{"/", http.MethodGet, "/files/*path/more", http.MethodGet, true},
+		{"/", http.MethodGet, "/users/x:id", http.MethodGet, true},
+		{"/", http.MethodGet, "/users/:", http.MethodGet, true},
+	}
+
+	for _, tc := range tests {
+		tree := &node{}
+		tree.insert(tc.existing).handle(tc.existing, tc.existingMethod, &endpoint{})
+		register := func() { tree.insert(tc.pattern).handle(tc.pattern, tc.method, &endpoint{}) }
+		if tc.conflict {
+			assert.Panics(t, register, "Expected %q to conflict with %q", tc.pattern, tc.existing)
+		} else {
+			assert.NotPanics(t, register, "Expected %q not to conflict with %q", tc.pattern, tc.existing)
+		}
+	}
+}
+
+func TestTreeStaticLookupDoesNotAllocate(t *testing.T) {
+	tree := newTestTree("/users", "/users/:id", "/users/:id/posts")
+
+	var params []pathParam
+	allocs := testing.AllocsPerRun(100, func() {
+		tree.lookup("/users", &params, func(*leaf) bool { return true })
+	})
+	assert.Equal(t, float64(0), allocs)
+}
+
+// benchmarkPaths returns n static paths shaped like a typical REST API
+func benchmarkPaths(n int) []string {
+	paths := make([]string, 0, n)
+	for i := 0; len(paths) < n; i++ {
+		resource := fmt.Sprintf("/api/v1/resource%d", i)
+		paths = append(paths, resource, resource+"/items", resource+"/items/archive", resource+"/settings")
+	}
+	return paths[:n]
+}
+
+func BenchmarkTreeStatic(b *testing.B) {
+	paths := benchmarkPaths(4000)
+	tree := &node{}
+	for _, p := range paths {
+		n := tree.insert(p)
+		n.leaves = append(n.leaves, newLeaf(p))
+	}
+
+	var params []pathParam
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.lookup(paths[i%len(paths)], &params, func(*leaf) bool { return true })
+	}
+}
+
+func BenchmarkTreeParam(b *testing.B) {
+	paths := benchmarkPaths(4000)
+	tree := &node{}
+	for _, p := range paths {
+		n := tree.insert(p)
+		n.leaves = append(n.leaves, newLeaf(p))
+	}
+	for i := 0; i < 1000; i++ {
+		p := fmt.Sprintf("/api/v2/resource%d/:id/items/:itemId", i)
+		n := tree.insert(p)
+		n.leaves = append(n.leaves, newLeaf(p))
+	}
+
+	requests := make([]string, 1000)
+	for i := range requests {
+		requests[i] = fmt.Sprintf("/api/v2/resource%d/42/items/7", i)
+	}
+
+	params := make([]pathParam, 0, 4)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		params = params[:0]
+		tree.lookup(requests[i%len(requests)], &params, func(*leaf) bool { return true })
+	}
+}
+
+// BenchmarkMapStatic measures the flat map lookup the router used before the radix tree
+func BenchmarkMapStatic(b *testing.B) {
+	paths := benchmarkPaths(4000)
+	routes := make(map[string]map[string]Handler)
+	for _, p := range paths {
+		routes[strings.Trim(p, "/")] = map[string]Handler{"GET": HandlerFunc(homeHandler)}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if methodHandlers, ok := routes[strings.Trim(paths[i%len(paths)], "/")]; ok {
+			_ = methodHandlers["GET"]
+		}
+	}
+}
+
+func BenchmarkRouterStatic(b *testing.B) {
+	paths := benchmarkPaths(4000)
+	route := NewRouter()
+	for _, p := range paths {
+		route.Handle(p, http.MethodGet, HandlerFunc(func(*Request, *Response) error { return nil }))
+	}
+
+	rr := httptest.NewRecorder()
+	reqs := make([]*http.Request, len(paths))
+	for i, p := range paths {
+		reqs[i] = httptest.NewRequest(http.MethodGet, p, nil)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		route.ServeHTTP(rr, reqs[i%len(reqs)])
+	}
+}